@@ -11,4 +11,12 @@ type (
 	that are used for interpolating translated phrase.
 	*/
 	Args map[string]interface{}
+
+	/*
+	InterpFunc is a function that transforms an interpolation argument
+	to the string, used for the interpolation verbs of "{{<func>:<name>}}" format
+	(e.g: "{{upper:name}}").
+	See Client.RegisterInterpFunc() for more details.
+	*/
+	InterpFunc func(arg interface{}) string
 )
@@ -7,6 +7,7 @@ package privet
 
 import (
 	"bytes"
+	"sort"
 	"sync/atomic"
 	"unsafe"
 
@@ -15,10 +16,93 @@ import (
 
 type (
 	/*
-	TODO: comment
+	Preprocessor is a hook that may transform the raw content of each source
+	before it's decoded (e.g: strip non-standard comments).
+	It's called with the path of the source (or its caller's "file:line"
+	for RAW content), and must return a new (or the same) content
+	or an error, meaning the source is invalid.
+
+	See Client.SetPreprocessor() for more details.
+	*/
+	Preprocessor func(path string, content []byte) ([]byte, error)
+
+	/*
+	KeyTransform is a hook that rewrites each key of sources at the load time
+	(e.g: from "snake_case" to "PascalCase").
+	See Client.SetKeyTransform() for more details.
+	*/
+	KeyTransform func(key string) string
+
+	/*
+	OnMissing is a callback that is called each time there is no language phrase
+	for the requested translation key (e.g: to log it or to collect metrics).
+	See Client.SetOnMissing() for more details.
+	*/
+	OnMissing func(localeName, key string)
+
+	/*
+	MissingKeyHandler returns a string that is used instead of the language phrase,
+	if there is no one for the requested translation key.
+	See Client.SetMissingKeyHandler() for more details.
+	*/
+	MissingKeyHandler func(localeName, key string) string
+
+	/*
+	OnUnknownFilter is a callback that is called each time the interpolation verb
+	of "{{name|filter}}" or "{{filter:name}}" format refers to the filter
+	that is not registered.
+	See Client.SetOnUnknownFilter() for more details.
+	*/
+	OnUnknownFilter func(localeName, verb, filter string)
+
+	/*
+	NilArgRenderer returns a string that is used instead of the interpolation verb,
+	which argument is nil. See Client.SetNilArgRenderer() for more details.
+	*/
+	NilArgRenderer func(verb string) string
+
+	/*
+	OnTrace is a callback that receives the messages about the key points
+	of locales loading, if Config.TraceLoad is enabled.
+	See Client.SetOnTrace() for more details.
+	*/
+	OnTrace func(msg string)
+
+	/*
+	OnWatchError is a callback that receives the errors of reloading
+	the changed locale source files by Client.Watch().
+	See Client.SetOnWatchError() for more details.
+	*/
+	OnWatchError func(path string, err *ekaerr.Error)
+
+	/*
+	OnInterpolationIssue is a callback that receives the names of missing
+	and unused arguments of the language phrase interpolation,
+	if Config.DebugInterpolation is enabled.
+	See Client.SetOnInterpolationIssue() for more details.
+	*/
+	OnInterpolationIssue func(key string, missing, unused []string)
+
+	/*
+	Client is a set of sourced and loaded locales with its own config.
+	The package level functions (Source(), Load(), LC(), Tr(), etc) are aliases
+	for the methods of the package's default Client (see DefaultClient()).
+
+	The zero value is ready to use, and Clients are isolated from each other,
+	so an application may have several independent translation sets:
+
+	        var c privet.Client
+	        c.Source("./locales")
+	        c.Load()
+	        c.Tr("en_US", "Main/Greetings", nil)
 	*/
 	Client struct {
 
+		// maxSourceFileSize is Config.MaxSourceFileSize, 0 means unlimited.
+		// Protected by atomic operations.
+		// It's the first field to be 64-bit aligned on 32-bit platforms.
+		maxSourceFileSize int64
+
 		/*
 		state is a current state of the whole package.
 		The package provides to you some promises (contracts),
@@ -31,6 +115,11 @@ type (
 		*/
 		state uint32
 
+		// maxDirectoryDepth is Config.MaxDirectoryDepth,
+		// 0 means _SOURCE_MAX_RECURSIVELY_DIRECTORY_SCAN.
+		// Protected by atomic operations.
+		maxDirectoryDepth uint32
+
 		config struct {
 
 			// C-like bool variables. 1 - true, 0 - false.
@@ -40,18 +129,71 @@ type (
 			LCEmptyLocaleNameAsNil uint32
 			LCNotFoundLocaleAsNil  uint32
 			SkipParseFilepath      uint32
+
+			MissingKeyFallbackToLeaf uint32
+			ContinueOnSourceError    uint32
+			CollapseEmptyKeySegments uint32
+			SkipInvalidSources       uint32
+			PreserveKeyOrder         uint32
+
+			AllowSiblingLanguageFallback uint32
+			PruneEmptyNodes              uint32
+			StrictKeyPath                uint32
+			KeyTransformNodes            uint32
+			MissingKeyHumanize           uint32
+			RequireDefaultLocale         uint32
+			TraceLoad                    uint32
+			FallbackToDefault            uint32
+			DebugInterpolation           uint32
+			TrimPhrases                  uint32
+			CollapsePhraseSpaces         uint32
+			StrictMetadata               uint32
+			CompilePhrases               uint32
 		}
 
+		preprocessor unsafe.Pointer // *Preprocessor, nil if not set
+		interpFuncs  unsafe.Pointer // *map[string]InterpFunc, copy-on-write
+
+		metaDataLocaleKeys  unsafe.Pointer // *[]string in lower case, nil if not set
+		contentResolveOrder unsafe.Pointer // *[]SourceItemType, nil if not set
+		siblingPriority     unsafe.Pointer // *[]string of normalized locale names, nil if not set
+		keyTransform        unsafe.Pointer // *KeyTransform, nil if not set
+		onMissing           unsafe.Pointer // *OnMissing, nil if not set
+		missingKeyHandler   unsafe.Pointer // *MissingKeyHandler, nil if not set
+		onUnknownFilter     unsafe.Pointer // *OnUnknownFilter, nil if not set
+		nilArgRenderer      unsafe.Pointer // *NilArgRenderer, nil if not set
+		onTrace             unsafe.Pointer // *OnTrace, nil if not set
+		onWatchError        unsafe.Pointer // *OnWatchError, nil if not set
+		onInterpIssue       unsafe.Pointer // *OnInterpolationIssue, nil if not set
+		safePlaceholder     unsafe.Pointer // *string, nil if not set
+		arraySeparator      unsafe.Pointer // *string, nil if not set
+		ignoreKeyPrefixes   unsafe.Pointer // *[]string, nil if not set
+		autoDefaultLocale   unsafe.Pointer // *string of normalized locale name, nil if not set
+		keyDelimiters       unsafe.Pointer // *string of accepted delimiters, nil if not set
+		fallbacks           unsafe.Pointer // *map[string][]string of normalized locale names, copy-on-write
+
 		defaultLocale unsafe.Pointer
 
-		storage,
-		storageTmp map[string]*Locale
+		storage    unsafe.Pointer     // *loadedStorage, replaced as a whole by Load()
+		storageTmp map[string]*Locale // storage under construction during Load()
+
+		sourcesTmp []SourceItem // sources under loading during Load()
 
-		sources,
-		sourcesTmp []SourceItem
+		report    unsafe.Pointer // *LoadReport of the last Load() call
+		reportTmp *LoadReport    // LoadReport under construction during Load()
 
 		buf bytes.Buffer
+	}
 
+	/*
+	loadedStorage is what Load() (and others) publishes as a whole:
+	the loaded Locales by their names and the sources they are loaded from,
+	so the readers always get the sources the Locales are constructed from.
+	Neither of fields is changed after loadedStorage is published.
+	*/
+	loadedStorage struct {
+		locales      map[string]*Locale
+		sources      []SourceItem
 		phrasesTotal uint64
 		localesTotal uint32
 	}
@@ -90,8 +232,21 @@ and will lead to fast return error).
 As we know from the above, there is only some "base" types and arrays are allowed.
 Base types are:
 
- - string (treated as path to either locale's directory or locale's one file),
- - []byte (treated as the content of locale's file).
+ - string (treated as path to either locale's directory or locale's one file,
+   or as a glob pattern if it contains any of "*", "?", "[" (see filepath.Match()),
+   e.g: "locales/*_prod.yml"; it's an error if pattern matches nothing),
+ - []byte (treated as the content of locale's file),
+ - RawSource (treated as the content of locale's file of the stated format
+   and locale name, see RawSource),
+ - *os.File, fs.File (treated as locale's file, its content is read immediately,
+   a file's name is used to find a locale name the same way as for a path;
+   if a file has no name or its extension is not supported,
+   the content must contain the metadata with locale name; file is not closed),
+ - io.Reader, io.ReadCloser (treated as the content of locale's file,
+   that is read till EOF immediately; reader is not closed),
+ - fs.FS, embed.FS (treated as a file system that is scanned recursively
+   starting from its root like a locale's directory, paths inside it are used
+   to find a locale name the same way as for a real path).
 
 Adding arrays to the list above and we've also get:
 
@@ -105,8 +260,12 @@ Also for passed []byte (or [][]byte).
 
 Keep in mind, Load() call flushes all pending sources.
 Thus you will need to re-register sources you want to use as source again
-after Load() call,
-if you need to call Load() more than one times (dunno why you even need that).
+after Load() call, if you want to load them again.
+
+Locales can be added incrementally: Source(), Load(), Source() more, Load() again.
+Already loaded locales are kept, the next Load() adds the new ones
+and merges new sources of already loaded locales into them, by translation keys.
+Duplicated keys are handled the same way as for sources of one Load() call.
 
 Path to directory or file might be absolute or relative.
 If relative it will converted to absolutely starting from the current work directory.
@@ -116,18 +275,205 @@ In that case all these sub-directories will be scanned too recursively,
 till locale files found.
 */
 func (c *Client) Source(args ...interface{}) *ekaerr.Error {
-	return c.source(args).Throw()
+	return c.source(args, nil).Throw()
+}
+
+/*
+SourceWithOptions is the same as Source() but applies passed SourceOptions
+to each source of that call. See SourceOptions for more details.
+*/
+func (c *Client) SourceWithOptions(opts SourceOptions, args ...interface{}) *ekaerr.Error {
+	return c.source(args, &opts).Throw()
+}
+
+/*
+SourceFlat is the same as Source() but adds a source of locale with passed name,
+which language phrases are presented as a flat map of translation key
+to the language phrase, where key's segments are separated by passed delimiter,
+like the most of translation management services export:
+
+        privet.SourceFlat("en_US", map[string]string{
+            "menu.file.open":  "Open",
+            "menu.file.close": "Close",
+        }, '.')
+
+So, it's the same as sourcing:
+
+        menu:
+          file:
+            open: "Open"
+            close: "Close"
+
+Returns an error if locale name is invalid, the map is empty,
+some key has an empty segment (like "menu..open") or some key is both
+a phrase and a node of other keys (like "menu" and "menu.file").
+*/
+func (c *Client) SourceFlat(localeName string, flat map[string]string, delimiter byte) *ekaerr.Error {
+	return c.source([]interface{}{sourceFlatArg{localeName, flat, delimiter}}, nil).Throw()
 }
 
 /*
-TODO: comment
+SourceDir is the same as Source() of the path (or glob pattern) to the directory,
+but allows you to opt out of the scanning of included directories.
+If recursive is false, only the files directly in the directory are counted,
+so the included directories may mean something else (e.g: per-tenant locales).
+Source() always scans included directories recursively.
+*/
+func (c *Client) SourceDir(path string, recursive bool) *ekaerr.Error {
+	return c.source([]interface{}{sourceDirArg{path, recursive}}, nil).Throw()
+}
+
+/*
+AddLocale adds language phrases of passed nested map to the Locale with passed name,
+creating it if it's not loaded yet, w/o any file or RAW source.
+It's useful for translations that are generated at runtime or in tests:
+
+        c.AddLocale("en_US", map[string]interface{}{
+            "menu": map[string]interface{}{
+                "open": "Open",
+            },
+        })
+
+Nested nodes must be of map[string]interface{} type.
+It's the same as a Source() of that map and Load() right after,
+so the same rules are applied (overwriting, inheritance, stats, etc),
+and readers never see a partially added Locale.
+Keep in mind, pending sources that were counted by Source() are loaded too.
+
+Returns an error if locale name is invalid, the map is empty
+or it can not be loaded.
+*/
+func (c *Client) AddLocale(name string, content map[string]interface{}) *ekaerr.Error {
+	if err := c.source([]interface{}{sourceMapArg{name, content}}, nil); err.IsNotNil() {
+		return err.Throw()
+	}
+	return c.load().Throw()
+}
+
+/*
+Load loads all locales from the sources that were counted by Source() calls.
+Pending sources are flushed, no matter whether it's successful or not.
+
+If Load() has been called successfully before and there is no new sources
+counted by Source() since, it's a no-op that returns nil (locales are loaded already).
+So it's safe to call Load() defensively, e.g. from the several initialization paths.
+
+Returns an error if there is no sources to load and locales are not loaded yet,
+if sources can not be loaded or if another Source() or Load() call is in progress.
+Previously loaded locales are still available if a new Load() call is failed.
 */
 func (c *Client) Load() *ekaerr.Error {
 	return c.load().Throw()
 }
 
+/*
+ReloadFile re-reads and re-parses one already loaded locale source file
+by its path (absolute or relative to the current work directory),
+replacing its previous language phrases by the new ones.
+Other sources are not re-read. It's designed for dev servers,
+that reload changed files w/o re-sourcing everything.
+
+The locale of the file is rebuilt from all its sources in the order they are loaded,
+so the phrases of the previous version of the file are removed, the phrases
+of other sources it has overwritten are restored, and the locale's metadata
+(inherits, fallbacks, required args) is collected again.
+Other sources are loaded again from the content they have been read with.
+The same config as for Load() is used (overwriting, preprocessing, etc).
+
+Returns an error if locales are not loaded yet, there is no loaded
+source file with that path, or it can not be loaded anymore.
+Loaded locales are not changed in that case.
+*/
+func (c *Client) ReloadFile(path string) *ekaerr.Error {
+	return c.reloadFile(path).Throw()
+}
+
+/*
+Reload re-reads and re-parses all loaded locale source files by their paths,
+that are remembered by Load(), and replaces the loaded locales by the new ones
+atomically. It's the same as Source() the same files again and Load() them,
+but w/o re-registering them.
+
+RAW data sources ([]byte, RawSource, io.Reader, maps, etc) and the files of fs.FS
+can't be re-read, so they are loaded again from the content they have been read with.
+The same config as for Load() is used (overwriting, preprocessing, etc).
+
+Returns an error if locales are not loaded yet or any file can not be loaded anymore.
+Loaded locales are not changed in that case.
+*/
+func (c *Client) Reload() *ekaerr.Error {
+	return c.reload().Throw()
+}
+
+/*
+Unload frees the loaded locales, so the Client is like no one locale
+was loaded yet. It's designed for long-running processes, that re-configure
+supported languages and want to release the memory of the old locale set.
+
+Loaded sources, default locale and the counters of loaded phrases are dropped,
+but the sources that are counted by Source() and not loaded yet
+are kept for the next Load() call. Config is kept as well.
+So, it's OK to call Unload() between Source() and Load(), to load
+the new sources only, instead of merging them into the loaded locales.
+
+Locale objects that are already obtained by LC() are still usable,
+but LC() returns nil after Unload() (or the default Locale, that is nil too),
+so Tr() returns the _SPTR_LOCALE_IS_NIL special string.
+
+Returns an error if locales are not loaded yet
+or if another Source() or Load() call is in progress.
+*/
+func (c *Client) Unload() *ekaerr.Error {
+	return c.unload().Throw()
+}
+
+/*
+Merge folds the loaded locales of other Client into the current one's,
+e.g: to apply a per-request overlay to the base translation pack.
+The locales other has only are added, the phrases of the same locales are merged.
+If the phrase of the same translation key is loaded by both of Clients,
+other's one is used only if overwrite is true. The same is for the metadata
+of the same locales (parent, fallbacks, list formatting rules, required args).
+
+The loaded sources of other are added to the current Client's ones,
+so Reload() re-reads other's files too. Other Client is not changed.
+Default locale of the current Client is kept.
+
+Locale objects that are already obtained by LC() are not changed,
+get them again to use merged ones.
+
+Returns an error if locales are not loaded yet by either of Clients,
+if other is the current Client, if another Source() or Load() call
+of the current Client is in progress, or if the merged locales
+inherit the ones that are not loaded. Loaded locales are not changed in that case.
+Other Client may be used (and even loaded) concurrently, the locales
+it has loaded by the time of the call are merged.
+*/
+func (c *Client) Merge(other *Client, overwrite bool) *ekaerr.Error {
+	return c.merge(other, overwrite).Throw()
+}
+
+/*
+VerifySources re-reads each loaded locale source file and returns the paths
+of the files, which MD5 hash sum differs from the one they had when were loaded
+(they have been changed or removed since then), in the order they were loaded.
+It's a lightweight drift detector, a periodic job may call it
+to decide whether to reload locales (see ReloadFile(), Load()).
+Loaded locales are not changed.
+
+Returns an error if locales are not loaded yet or some file can not be read.
+*/
+func (c *Client) VerifySources() ([]string, *ekaerr.Error) {
+	changed, err := c.verifySources()
+	return changed, err.Throw()
+}
+
 /*
 LC returns the requested Locale by its name.
+The name is case insensitive and "-" might be used as a separator,
+so "en-us", "EN_US", "En_Us" are the same as "en_US".
+"Accept-Language" HTTP header value might be passed as is
+(e.g: "en-US,en;q=0.9,ru;q=0.8"), the most preferred loaded Locale is returned then.
 
 If the Locale with the specified name doesn't exists (or if name is empty):
  - Default Locale is returned if any locale marked as default;
@@ -142,6 +488,15 @@ You may change that behaviour using:
  2. Config.LCNotFoundLocaleAsNil set to true (false by default)
     if you want to get nil Locale if Locale with requested name not found
     (even if any Locale is marked as default).
+
+ 3. Config.AllowSiblingLanguageFallback set to true (false by default)
+    if you want to get a loaded Locale of the same language but another region
+    (e.g: "pt_PT" for "pt_BR"), if Locale with requested name not found.
+    See Client.SetSiblingPriority() to choose which region is preferred.
+
+It's safe to call LC() concurrently with Source(), Load(), Reload(), etc.
+The Locales of the last successful loading are returned until the new ones
+are published, so they never disappear while locales are (re)loaded.
 */
 func (c *Client) LC(name string) *Locale {
 
@@ -160,6 +515,9 @@ func (c *Client) LC(name string) *Locale {
 	if loc := c.getLocale(name); loc != nil {
 		return loc
 
+	} else if loc = c.getSiblingLocale(name); loc != nil {
+		return loc
+
 	} else if atomic.LoadUint32(&c.config.LCNotFoundLocaleAsNil) == 0 {
 		return c.getDefaultLocale()
 
@@ -183,6 +541,175 @@ func (c *Client) Default() *Locale {
 	return c.getDefaultLocale()
 }
 
+/*
+KeyDelimiter returns a delimiter of translation key's segments
+(e.g: '/' for "Menu/File/Open"). It's DEFAULT_DELIMITER,
+unless another one is set using SetKeyDelimiters() (the first one then).
+Full translation keys are joined by this delimiter (e.g: Keys(), Snapshot()).
+Keys of sources that contain any accepted delimiter are unreachable,
+see LoadReport.Warnings.
+*/
+func (c *Client) KeyDelimiter() byte {
+	if !c.isValid() {
+		return DEFAULT_DELIMITER
+	}
+	return c.getKeyDelimiters()[0]
+}
+
+/*
+LastLoadReport returns a LoadReport of the last Load() call, successful or not.
+Returns nil if Load() has not been called yet.
+
+Returned LoadReport must not be modified.
+*/
+func (c *Client) LastLoadReport() *LoadReport {
+	if !c.isValid() {
+		return nil
+	}
+	return (*LoadReport)(atomic.LoadPointer(&c.report))
+}
+
+/*
+FormatStats returns how many sources of each SourceItemType
+were used to construct currently loaded locales.
+Use SourceItemType.String() to present the stats in a human readable way,
+e.g: "loaded 12 YAML file, 3 TOML file".
+
+Returns nil if there is no loaded locales yet.
+*/
+func (c *Client) FormatStats() map[SourceItemType]int {
+
+	if !c.isValid() {
+		return nil
+	}
+
+	loaded := c.getLoadedStorage()
+	if loaded == nil {
+		return nil
+	}
+
+	stats := make(map[SourceItemType]int)
+	for _, sourceItem := range loaded.sources {
+		stats[sourceItem.Type]++
+	}
+
+	return stats
+}
+
+/*
+Snapshot returns all language phrases of all loaded locales at once,
+as a map of locale name to the map of translation key to the language phrase.
+Translation keys are full ones, joined by KeyDelimiter() (e.g: "Menu/File/Open").
+Inherited language phrases are not included.
+
+It's useful to export translations to some translation management tool.
+Returned maps are new ones, you may modify them.
+It's safe to call Snapshot() concurrently with translation methods.
+
+Returns nil if there is no loaded locales yet.
+*/
+func (c *Client) Snapshot() map[string]map[string]string {
+
+	if !c.isValid() {
+		return nil
+	}
+
+	storage := c.getStorage()
+	if storage == nil {
+		return nil
+	}
+
+	snapshot := make(map[string]map[string]string, len(storage))
+	for localeName, loc := range storage {
+		snapshot[localeName] = loc.flatten()
+	}
+
+	return snapshot
+}
+
+/*
+LocalesByLanguage returns the names of all loaded locales grouped by
+their language part (e.g: "en" -> ["en_GB", "en_US"]).
+Names of each group are sorted lexicographically.
+It's useful for language pickers grouped by language.
+Returned map is a new one, you may modify it.
+
+Returns nil if there is no loaded locales yet.
+*/
+func (c *Client) LocalesByLanguage() map[string][]string {
+
+	if !c.isValid() {
+		return nil
+	}
+
+	storage := c.getStorage()
+	if storage == nil {
+		return nil
+	}
+
+	byLanguage := make(map[string][]string)
+	for localeName := range storage {
+		language := localeLanguage(localeName)
+		byLanguage[language] = append(byLanguage[language], localeName)
+	}
+
+	for _, localeNames := range byLanguage {
+		sort.Strings(localeNames)
+	}
+
+	return byLanguage
+}
+
+/*
+Languages returns the distinct language parts of the names of all loaded locales
+(e.g: ["en", "ru"] for "en_US", "en_GB", "ru_RU"), sorted lexicographically.
+
+Returns nil if there is no loaded locales yet.
+*/
+func (c *Client) Languages() []string {
+
+	byLanguage := c.LocalesByLanguage()
+	if byLanguage == nil {
+		return nil
+	}
+
+	languages := make([]string, 0, len(byLanguage))
+	for language := range byLanguage {
+		languages = append(languages, language)
+	}
+
+	sort.Strings(languages)
+	return languages
+}
+
+/*
+WhoHas returns the names of loaded locales that have a language phrase
+for the passed key and the names of those that don't, both sorted lexicographically.
+Only the locale's own phrases are counted, not the ones it inherits,
+so it answers which locales still need the phrase to be translated.
+
+Returns nils if there is no loaded locales yet.
+*/
+func (c *Client) WhoHas(key string) (has, missing []string) {
+
+	if !c.isValid() {
+		return nil, nil
+	}
+
+	for localeName, loc := range c.getStorage() {
+		if _, _, class := loc.lookup(key); class == "" {
+			has = append(has, localeName)
+		} else {
+			missing = append(missing, localeName)
+		}
+	}
+
+	sort.Strings(has)
+	sort.Strings(missing)
+
+	return has, missing
+}
+
 /*
 Tr is an alias for Client.LC(localeName).Tr(key, args).
 See LC() function and Locale.Tr() method for more details.
@@ -190,3 +717,59 @@ See LC() function and Locale.Tr() method for more details.
 func (c *Client) Tr(localeName, key string, args Args) string {
 	return c.LC(localeName).Tr(key, args)
 }
+
+/*
+TrChain tries to translate the key using each Locale from the localeNames
+in the order they are passed, and returns the first found language phrase
+interpolated using args, if any.
+Not loaded locales are skipped.
+
+It's useful, when the locales preference order is request specific
+(e.g: it's taken from "Accept-Language" HTTP header).
+Each Locale is consulted the same way Locale.Tr() does
+(plural and gender forms, the Locales it inherits, its fallback locales).
+
+If there is no language phrase for the key in any of the requested locales,
+the special string of the first Locale that has no phrase is returned
+(the same Locale.Tr() would return; e.g: the key is a node there).
+If no one of the requested locales is loaded, it's _SPTR_LOCALE_IS_NIL.
+*/
+func (c *Client) TrChain(key string, args Args, localeNames ...string) string {
+
+	var (
+		firstLoc   *Locale
+		firstClass _SpecialTranslationClass
+	)
+
+	for i, n := 0, len(localeNames); i < n && c.isValid(); i++ {
+		loc := c.getLocale(localeNames[i])
+		if loc == nil {
+			continue
+		}
+
+		switch translatedPhrase, tokens, class := loc.lookupCounted(key, args); {
+
+		case class != "":
+			if firstLoc == nil {
+				firstLoc, firstClass = loc, class
+			}
+
+		case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+			return newInterpolator(loc, key, translatedPhrase, args).withTokens(tokens).interpolate()
+
+		default:
+			return translatedPhrase
+		}
+	}
+
+	switch {
+	case firstLoc == nil:
+		return sptr(_SPTR_LOCALE_IS_NIL, key)
+
+	case firstClass == _SPTR_TRANSLATION_NOT_FOUND:
+		return firstLoc.trMissing(key)
+
+	default:
+		return sptr(firstClass, key)
+	}
+}
@@ -0,0 +1,953 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strings"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+)
+
+/*
+SetMissingKeyFallbackToLeaf sets Config.MissingKeyFallbackToLeaf.
+
+If it's true, Locale.Tr() returns the last segment of translation key
+(e.g: "Open" for "Menu/File/Open") if there is no language phrase for that key,
+instead of _SPTR_TRANSLATION_NOT_FOUND special string.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetMissingKeyFallbackToLeaf(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.MissingKeyFallbackToLeaf, enable)
+}
+
+/*
+SetMissingKeyHumanize sets Config.MissingKeyHumanize.
+
+If it's true, Locale.Tr() returns the humanized last segment of translation key
+(e.g: "File Open" for "Menu/FileOpen" or "Menu/file_open") if there is no
+language phrase for that key. Words are split by "_", "-", "." and case boundaries,
+and each one is title-cased. It's a friendly fallback for rapid prototyping.
+It has priority over Config.MissingKeyFallbackToLeaf. It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetMissingKeyHumanize(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.MissingKeyHumanize, enable)
+}
+
+/*
+SetContinueOnSourceError sets Config.ContinueOnSourceError.
+
+If it's true, Load() doesn't abort loading a source at the first
+duplicated translation key (when Config.OverwriteExistingKey is false),
+but keeps the old language phrase, adds a LoadConflict to the LoadReport
+and continues. So you can get all conflicts at once using Client.LastLoadReport().
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetContinueOnSourceError(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.ContinueOnSourceError, enable)
+}
+
+/*
+SetCollapseEmptyKeySegments sets Config.CollapseEmptyKeySegments.
+
+If it's true, leading, trailing and repeated delimiters of translation key
+are ignored by Locale.Tr() and similar methods, so "Menu//File/" is the same as "Menu/File".
+Useful for programmatically joined keys.
+Otherwise such keys are treated as incorrect ones. It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetCollapseEmptyKeySegments(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.CollapseEmptyKeySegments, enable)
+}
+
+/*
+SetSkipInvalidSources sets Config.SkipInvalidSources.
+
+If it's true, Load() doesn't fail if some source can not be loaded
+(malformed content, failed Preprocessor, duplicated keys, etc),
+but skips it, adding a LoadSkippedSource to the LoadReport
+(see Client.LastLoadReport()). None of phrases of the skipped source are loaded.
+Load() fails anyway if there is no loaded phrases at all.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetSkipInvalidSources(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.SkipInvalidSources, enable)
+}
+
+/*
+SetPreprocessor sets a Preprocessor that will be called for each source
+by the next Load() call, before its content is decoded.
+It's an escape hatch for the sources of odd formats,
+like YAML files with non-standard "//" comments:
+
+        privet.SetPreprocessor(func(path string, content []byte) ([]byte, error) {
+            return stripSlashComments(content), nil
+        })
+
+If Preprocessor returns an error, Load() is failed
+(or the source is skipped if Config.SkipInvalidSources is true).
+Pass nil to remove Preprocessor.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetPreprocessor(fn Preprocessor) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.preprocessor, ptr)
+}
+
+/*
+RegisterInterpFunc registers an InterpFunc with the passed name,
+that will be used for the interpolation verbs of "{{<name>:<arg>}}" format.
+The argument with <arg> name is passed to the fn, and the returned string
+is used instead of the verb:
+
+        privet.RegisterInterpFunc("upper", func(arg interface{}) string {
+            return strings.ToUpper(fmt.Sprint(arg))
+        })
+        // "Hello, {{upper:name}}!" + Args{"name": "Alice"} -> "Hello, ALICE!"
+
+Registered InterpFunc might be also used as a filter of the filters pipeline,
+which are applied from left to right, each to the result of the previous one:
+
+        // "{{name|trim|upper}}" -> upper(trim(name))
+
+An argument with the verb's full name (including ':' or '|') has priority.
+Verbs of unknown functions or w/o argument are kept untouched.
+If there is an InterpFunc with the same name, it's replaced.
+Pass nil fn to unregister InterpFunc. Empty name is ignored.
+
+It's safe to call this method concurrently with translation methods.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) RegisterInterpFunc(name string, fn InterpFunc) {
+	if !c.isValid() || name == "" {
+		return
+	}
+
+	for {
+		oldPtr := atomic.LoadPointer(&c.interpFuncs)
+
+		var oldInterpFuncs map[string]InterpFunc
+		if oldPtr != nil {
+			oldInterpFuncs = *(*map[string]InterpFunc)(oldPtr)
+		}
+
+		newInterpFuncs := make(map[string]InterpFunc, len(oldInterpFuncs) + 1)
+		for oldName, oldFn := range oldInterpFuncs {
+			newInterpFuncs[oldName] = oldFn
+		}
+		if fn != nil {
+			newInterpFuncs[name] = fn
+		} else {
+			delete(newInterpFuncs, name)
+		}
+
+		if atomic.CompareAndSwapPointer(&c.interpFuncs, oldPtr, unsafe.Pointer(&newInterpFuncs)) {
+			return
+		}
+	}
+}
+
+/*
+SetFallback sets the fallback chain of the locale with passed name:
+the locales the language phrase is looked up in (in the passed order)
+by Locale.Tr(), if there is no phrase for the requested key
+in that locale and the locales it inherits, e.g:
+
+        privet.SetFallback("ru_UA", "ru_RU", "en_US")
+
+Fallback chains are not transitive: the fallbacks of a fallback locale
+are not consulted (but the locales it inherits are), so there is no infinite loop
+even if the chains are cyclic. Not loaded fallback locales are skipped.
+The default locale might be the final fallback of all chains,
+see SetFallbackToDefault().
+
+The chain might be declared by the locale's metadata as well,
+using "fallback" field (or "fallbacks" for more than one locale).
+The chain set by this method takes precedence over the declared one.
+
+Names are case insensitive and "-" might be used as a separator.
+Call it w/o fallbacks to remove the chain of the locale.
+It's safe to call this method concurrently with translation methods.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetFallback(localeName string, fallbacks ...string) {
+	if !c.isValid() {
+		return
+	}
+
+	localeName = normalizeLocaleName(localeName)
+
+	chain := make([]string, len(fallbacks))
+	for i, fallback := range fallbacks {
+		chain[i] = normalizeLocaleName(fallback)
+	}
+
+	for {
+		oldPtr := atomic.LoadPointer(&c.fallbacks)
+
+		var oldFallbacks map[string][]string
+		if oldPtr != nil {
+			oldFallbacks = *(*map[string][]string)(oldPtr)
+		}
+
+		newFallbacks := make(map[string][]string, len(oldFallbacks) + 1)
+		for oldLocaleName, oldChain := range oldFallbacks {
+			newFallbacks[oldLocaleName] = oldChain
+		}
+		if len(chain) != 0 {
+			newFallbacks[localeName] = chain
+		} else {
+			delete(newFallbacks, localeName)
+		}
+
+		if atomic.CompareAndSwapPointer(&c.fallbacks, oldPtr, unsafe.Pointer(&newFallbacks)) {
+			return
+		}
+	}
+}
+
+/*
+SetFallbackToDefault sets Config.FallbackToDefault.
+
+If it's true, the default locale (see Locale.MarkAsDefault()) is the final fallback
+of each locale (after its fallback chain, see SetFallback()), so Locale.Tr()
+returns the default locale's language phrase, if there is no phrase
+for the requested key neither in the current locale nor in its fallbacks.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetFallbackToDefault(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.FallbackToDefault, enable)
+}
+
+/*
+SetMaxSourceFileSize sets Config.MaxSourceFileSize, the maximum allowed size
+in bytes of each file (or opened file) passed to Source(), directly or by directory scan.
+Source() returns an error if some file is bigger.
+It's a guard against OOM, when you scan shared or untrusted directories.
+0 or negative value means unlimited. It's unlimited by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetMaxSourceFileSize(size int64) {
+	if !c.isValid() {
+		return
+	}
+	atomic.StoreInt64(&c.maxSourceFileSize, size)
+}
+
+/*
+SetMaxDirectoryDepth sets Config.MaxDirectoryDepth, the limit of nesting
+of directories Source() scans a directory (or fs.FS) with.
+The directory passed to Source() has depth 0, its subdirectories have 1, etc.
+Source() returns an error if some directory has the depth that reaches the limit,
+so 1 forbids the subdirectories at all. Increase it for deeply nested locale trees.
+0 or negative value means the default one, that is 16.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetMaxDirectoryDepth(depth int) {
+	if !c.isValid() {
+		return
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	atomic.StoreUint32(&c.maxDirectoryDepth, uint32(depth))
+}
+
+/*
+SetPreserveKeyOrder sets Config.PreserveKeyOrder.
+
+If it's true, the next Load() call records the order translation keys are declared
+in sources, and Locale.Keys(), Locale.Range() honor it. It makes exported
+translations reviewable against the originals.
+Otherwise keys are sorted lexicographically. It's false by default,
+because sources have to be decoded twice.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetPreserveKeyOrder(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.PreserveKeyOrder, enable)
+}
+
+/*
+SetTrimPhrases sets Config.TrimPhrases.
+
+If it's true, the next Load() call removes leading and trailing whitespaces
+(including new lines) of each loaded language phrase. It's useful for the phrases
+that are written using YAML block scalars, that keep the trailing new line:
+
+        Help: |
+          Type your name.
+
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetTrimPhrases(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.TrimPhrases, enable)
+}
+
+/*
+SetCollapsePhraseSpaces sets Config.CollapsePhraseSpaces.
+
+If it's true, the next Load() call replaces each run of whitespaces
+(spaces, tabs, new lines) of each loaded language phrase by one space,
+so the indentation and line breaks of multiline phrases are removed.
+Use it with Config.TrimPhrases to remove leading and trailing ones too.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetCollapsePhraseSpaces(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.CollapsePhraseSpaces, enable)
+}
+
+/*
+SetStrictMetadata sets Config.StrictMetadata.
+
+If it's true, the next Load() call fails for each source, the metadata of which
+has a field privet doesn't know about (e.g: a typo like "locael_name" or "inherit"),
+instead of silently ignoring it. The unknown fields are reported
+as "privet_metadata_unknown_keys" field of the error.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetStrictMetadata(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.StrictMetadata, enable)
+}
+
+/*
+SetCompilePhrases sets Config.CompilePhrases.
+
+If it's true, the next Load() call pre-splits each loaded language phrase
+that has interpolation verbs into the just text and verbs parts, so Tr()
+and other translation methods don't scan the phrase again for each call.
+It's useful for the hot paths rendering thousands of phrases,
+at the cost of the memory the split phrases take.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetCompilePhrases(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.CompilePhrases, enable)
+}
+
+/*
+SetMetadataLocaleKeys sets Config.MetadataLocaleKeys, the names of metadata's keys
+the locale name is looked up by (case insensitive), overriding the default ones:
+"locale_name", "localename", "locale", "name".
+Include them to extend the default set instead:
+
+        privet.SetMetadataLocaleKeys("locale", "name", "lang", "language", "code")
+
+Empty names are ignored. Call it w/o arguments (or with empty names only)
+to restore the default set.
+Takes effect for the next Load() call.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetMetadataLocaleKeys(keys ...string) {
+	if !c.isValid() {
+		return
+	}
+
+	var localeKeys []string
+	for _, key := range keys {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			localeKeys = append(localeKeys, key)
+		}
+	}
+
+	var ptr unsafe.Pointer
+	if len(localeKeys) != 0 {
+		ptr = unsafe.Pointer(&localeKeys)
+	}
+
+	atomic.StorePointer(&c.metaDataLocaleKeys, ptr)
+}
+
+/*
+SetContentResolveOrder sets Config.ContentResolveOrder, the decoders (and their order)
+that are tried to decode the RAW content of unknown format (e.g: []byte),
+until the first successful one.
+Allowed types are: SOURCE_ITEM_TYPE_CONTENT_JSON, SOURCE_ITEM_TYPE_CONTENT_YAML,
+SOURCE_ITEM_TYPE_CONTENT_TOML. By default JSON is tried first, then YAML, then TOML.
+
+If you know your content is TOML, pass SOURCE_ITEM_TYPE_CONTENT_TOML only,
+because YAML decoder is very permissive.
+Anyway, the content is considered decoded successfully only if it has a metadata
+section, because RAW content has no path to get the locale name from.
+
+Call it w/o arguments to restore the default order.
+Takes effect for the next Load() call.
+Returns an error if some type is not allowed or duplicated.
+*/
+func (c *Client) SetContentResolveOrder(types ...SourceItemType) *ekaerr.Error {
+	const s = "Failed to set the content resolve order. "
+
+	if !c.isValid() {
+		return ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+	}
+
+	if len(types) == 0 {
+		atomic.StorePointer(&c.contentResolveOrder, nil)
+		return nil
+	}
+
+	allowedTypes := loadContentResolveOrderDefault()
+	resolveOrder := make([]SourceItemType, 0, len(types))
+
+	for _, typ := range types {
+		isAllowed, isDuplicated := false, false
+		for _, allowedType := range allowedTypes {
+			isAllowed = isAllowed || typ == allowedType
+		}
+		for _, alreadyAddedType := range resolveOrder {
+			isDuplicated = isDuplicated || typ == alreadyAddedType
+		}
+		if !isAllowed || isDuplicated {
+			return ekaerr.IllegalArgument.
+				New(s + "Type is not allowed or duplicated.").
+				AddFields("privet_source_type", typ.String()).
+				Throw()
+		}
+		resolveOrder = append(resolveOrder, typ)
+	}
+
+	atomic.StorePointer(&c.contentResolveOrder, unsafe.Pointer(&resolveOrder))
+	return nil
+}
+
+/*
+SetAllowSiblingLanguageFallback sets Config.AllowSiblingLanguageFallback.
+
+If it's true, LC() returns a loaded Locale of the same language but of another region,
+if the requested one is not loaded (e.g: "pt_PT" for "pt_BR", "es_ES" for "es_MX").
+It's common for Portuguese, Spanish, Chinese variants.
+See SetSiblingPriority() to choose which region is preferred.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetAllowSiblingLanguageFallback(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.AllowSiblingLanguageFallback, enable)
+}
+
+/*
+SetSiblingPriority sets Config.SiblingPriority, the locales that are preferred
+(in the passed order) as a sibling language fallback
+(see SetAllowSiblingLanguageFallback()), e.g:
+
+        privet.SetSiblingPriority("pt_PT", "es_ES", "zh_CN")
+
+If there is no loaded locale from that list for the requested language,
+the first loaded locale of that language in lexicographical order is used.
+Call it w/o arguments to reset priority.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetSiblingPriority(localeNames ...string) {
+	if !c.isValid() {
+		return
+	}
+
+	var ptr unsafe.Pointer
+	if len(localeNames) > 0 {
+		priority := make([]string, len(localeNames))
+		for i, localeName := range localeNames {
+			priority[i] = normalizeLocaleName(localeName)
+		}
+		ptr = unsafe.Pointer(&priority)
+	}
+
+	atomic.StorePointer(&c.siblingPriority, ptr)
+}
+
+/*
+SetPruneEmptyNodes sets Config.PruneEmptyNodes.
+
+If it's true, the empty nodes (that have neither language phrases
+nor non-empty nested nodes) of all locales are removed at the end
+of each Load() call. See Locale.Prune() for more details.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetPruneEmptyNodes(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.PruneEmptyNodes, enable)
+}
+
+/*
+SetStrictKeyPath sets Config.StrictKeyPath.
+
+If it's true, Locale.Tr() (and other translation methods) distinguishes
+the translation key that points to the node, not a phrase
+(e.g: "Menu/File" if there is "Menu/File/Open"), from the absent one,
+returning _SPTR_TRANSLATION_KEY_IS_NODE special string instead of
+_SPTR_TRANSLATION_NOT_FOUND. It helps to debug keys that stop one segment short.
+It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetStrictKeyPath(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.StrictKeyPath, enable)
+}
+
+/*
+SetKeyTransform sets a KeyTransform that will be applied by the next Load() call
+to each key of phrases (and of nodes, if Config.KeyTransformNodes is set)
+of each source, before it's stored. So, duplicated keys are detected after rewriting,
+even if they are of the same source (e.g: "Title" and "title" lower cased).
+It allows to normalize keys of heterogeneous sources into one naming scheme
+w/o editing files:
+
+        privet.SetKeyTransform(strcase.ToCamel)
+
+Keys that become empty are an error of loading source.
+Pass nil to remove KeyTransform.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetKeyTransform(fn KeyTransform) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.keyTransform, ptr)
+}
+
+/*
+SetKeyTransformNodes sets Config.KeyTransformNodes.
+
+If it's true, KeyTransform (see SetKeyTransform()) is applied to the names
+of nodes as well (e.g: "Menu" and "File" of "Menu/File/Open"),
+otherwise only to the keys of phrases ("Open"). It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetKeyTransformNodes(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.KeyTransformNodes, enable)
+}
+
+/*
+SetOnMissing sets an OnMissing callback, that is called with the Locale's name
+and the requested translation key each time there is no language phrase for it
+(by Tr() and all its derivatives, including TrSafe()).
+The callback is called synchronously, so it must be fast and concurrent safe.
+Pass nil to remove OnMissing callback.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetOnMissing(fn OnMissing) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.onMissing, ptr)
+}
+
+/*
+SetMissingKeyHandler sets a MissingKeyHandler, that is called with the Locale's name
+and the requested translation key each time there is no language phrase for it
+(by Tr() and all its derivatives), and which result is returned instead of
+the special string, e.g:
+
+        privet.SetMissingKeyHandler(func(localeName, key string) string {
+            sentry.CaptureMessage("Missing translation: " + localeName + ": " + key)
+            return "[" + key + "]"
+        })
+
+It has priority over Config.MissingKeyFallbackToLeaf and Config.MissingKeyHumanize.
+OnMissing callback (see SetOnMissing()) is still called before.
+The handler is called synchronously, so it must be fast and concurrent safe.
+Pass nil to remove it, the special string is returned then.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetMissingKeyHandler(fn MissingKeyHandler) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.missingKeyHandler, ptr)
+}
+
+/*
+SetSafePlaceholder sets a string Locale.TrSafe() returns instead of special strings
+if there is no language phrase for the requested key, or the key is incorrect.
+It's an empty string by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetSafePlaceholder(placeholder string) {
+	if !c.isValid() {
+		return
+	}
+	atomic.StorePointer(&c.safePlaceholder, unsafe.Pointer(&placeholder))
+}
+
+/*
+SetArraySeparator sets a string the elements of arrays of sources are joined by,
+when they are loaded as one language phrase by the next Load() call, like:
+
+        Help:
+          - "First line."
+          - "Second line."
+
+The elements must be strings, numbers, bools or nulls, arrays of maps or arrays
+are prohibited. It's "\n" by default (see DEFAULT_ARRAY_SEPARATOR).
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetArraySeparator(separator string) {
+	if !c.isValid() {
+		return
+	}
+	atomic.StorePointer(&c.arraySeparator, unsafe.Pointer(&separator))
+}
+
+/*
+SetKeyDelimiters sets the delimiters translation keys are split by
+instead of DEFAULT_DELIMITER. Each of them is accepted, so keys might be
+split by any of them, even by the different ones in the same key
+(e.g: both "Main.Greetings" and "Main/Greetings" are accepted for '.' and '/').
+The first one is the primary delimiter, full translation keys are joined by
+(e.g: Locale.Keys(), Snapshot()), see KeyDelimiter().
+
+Keys of sources that contain any of them are unreachable by Tr(),
+see LoadReport.Warnings. Call it before Load() for these warnings to be correct.
+Pass nothing to restore DEFAULT_DELIMITER.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetKeyDelimiters(delimiters ...byte) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if len(delimiters) != 0 {
+		keyDelimiters := string(delimiters)
+		ptr = unsafe.Pointer(&keyDelimiters)
+	}
+	atomic.StorePointer(&c.keyDelimiters, ptr)
+}
+
+/*
+SetIgnoreKeyPrefix sets Config.IgnoreKeyPrefix, the prefixes of keys
+(of both phrases and nodes) that are skipped by the next Load() call.
+Skipped phrases are neither stored nor counted, so Tr() and Keys()
+do not know about them. It allows to keep work-in-progress phrases
+in the same files, but out of production:
+
+        privet.SetIgnoreKeyPrefix("_draft_", "TODO_")
+
+Prefixes are matched against the keys as they are in sources
+(before KeyTransform is applied). Empty prefixes are ignored.
+Call it w/o arguments to not ignore any key (the default).
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetIgnoreKeyPrefix(prefixes ...string) {
+	if !c.isValid() {
+		return
+	}
+
+	var ptr unsafe.Pointer
+	if len(prefixes) > 0 {
+		ignoreKeyPrefixes := make([]string, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			if prefix != "" {
+				ignoreKeyPrefixes = append(ignoreKeyPrefixes, prefix)
+			}
+		}
+		if len(ignoreKeyPrefixes) > 0 {
+			ptr = unsafe.Pointer(&ignoreKeyPrefixes)
+		}
+	}
+
+	atomic.StorePointer(&c.ignoreKeyPrefixes, ptr)
+}
+
+/*
+SetOnUnknownFilter sets an OnUnknownFilter callback, that is called
+with the Locale's name, the verb and the filter's name each time
+the interpolation verb of "{{name|filter}}" or "{{filter:name}}" format
+(see RegisterInterpFunc()) refers to the filter that is not registered.
+Such verbs are kept untouched.
+The callback is called synchronously, so it must be fast and concurrent safe.
+Pass nil to remove OnUnknownFilter callback.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetOnUnknownFilter(fn OnUnknownFilter) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.onUnknownFilter, ptr)
+}
+
+/*
+SetAutoDefaultLocale sets Config.AutoDefaultLocale, the name of Locale
+that is marked as default (see Locale.MarkAsDefault()) by Load() call,
+if there is no default Locale yet (or it's not loaded anymore).
+So, the default Locale might be declared before locales are loaded.
+Pass an empty string to not mark any Locale automatically (the default).
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetAutoDefaultLocale(localeName string) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if localeName = normalizeLocaleName(localeName); localeName != "" {
+		ptr = unsafe.Pointer(&localeName)
+	}
+	atomic.StorePointer(&c.autoDefaultLocale, ptr)
+}
+
+/*
+SetRequireDefaultLocale sets Config.RequireDefaultLocale.
+
+If it's true, Load() returns an error (and locales are not loaded)
+if there is no default Locale after loading, even after Config.AutoDefaultLocale
+is applied. It catches a misconfiguration, when LC("") returns nil
+and each Tr() of it silently returns a special string. It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetRequireDefaultLocale(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.RequireDefaultLocale, enable)
+}
+
+/*
+SetNilArgRenderer sets a NilArgRenderer, that is called with the verb's name
+(e.g: "name" for "{{name}}") when the interpolation argument is nil,
+including typed nil pointers, maps, slices, etc. The returned string is used
+instead of the verb. By default an empty string is used,
+so Go's "<nil>" is never leaked to the UI text:
+
+        privet.SetNilArgRenderer(func(verb string) string {
+            return "—"
+        })
+
+Pass nil to restore the default behaviour.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetNilArgRenderer(fn NilArgRenderer) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.nilArgRenderer, ptr)
+}
+
+/*
+SetTraceLoad sets Config.TraceLoad.
+
+If it's true and OnTrace callback is set (see SetOnTrace()),
+Load() reports the key points of loading to it: how many sources are loaded,
+the format of each source, its resolved locale name, how many phrases are stored,
+skipped sources, etc. It makes loading debuggable (e.g: "my file is loaded
+but keys are missing"). It's false by default.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetTraceLoad(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.TraceLoad, enable)
+}
+
+/*
+SetOnTrace sets an OnTrace callback, that receives the human readable messages
+about loading if Config.TraceLoad is enabled (see SetTraceLoad()),
+so they might be passed to your own logger:
+
+        privet.SetOnTrace(func(msg string) {
+            log.Println("i18n:", msg)
+        })
+        privet.SetTraceLoad(true)
+
+The callback is called synchronously by Load(). Pass nil to remove OnTrace callback.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetOnTrace(fn OnTrace) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.onTrace, ptr)
+}
+
+/*
+SetOnWatchError sets an OnWatchError callback, that receives the errors
+of reloading the changed locale source files by Watch(), e.g:
+
+        privet.SetOnWatchError(func(path string, err *ekaerr.Error) {
+            err.LogAsError("Failed to reload locale file")
+        })
+
+Previously loaded locales are kept in that case.
+The callback is called synchronously by Watch(). Pass nil to remove it.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetOnWatchError(fn OnWatchError) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.onWatchError, ptr)
+}
+
+/*
+SetDebugInterpolation sets Config.DebugInterpolation.
+
+If it's true, the interpolation of language phrases collects the names
+of arguments the verbs refer to but that are not passed (missing),
+and the names of passed arguments that are never used (unused),
+and reports them to the OnInterpolationIssue callback
+(see SetOnInterpolationIssue()). It's useful during development.
+
+If it's false (default), the diagnostics are not collected at all.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetDebugInterpolation(enable bool) {
+	if !c.isValid() {
+		return
+	}
+	c.setConfigFlag(&c.config.DebugInterpolation, enable)
+}
+
+/*
+SetOnInterpolationIssue sets an OnInterpolationIssue callback, that receives
+the translation key and the names of missing and unused (sorted) arguments
+of each interpolation that has any, if Config.DebugInterpolation is enabled
+(see SetDebugInterpolation()), e.g:
+
+        privet.SetOnInterpolationIssue(func(key string, missing, unused []string) {
+            log.Printf("Phrase %q: missing args %v, unused args %v", key, missing, unused)
+        })
+
+The arguments of Locale.WithArgs() view and the reserved "count" and "gender"
+arguments, that select the plural and gender form of the phrase,
+are never reported as unused. The filter of the verb that is not registered
+is reported by OnUnknownFilter callback (see SetOnUnknownFilter()),
+its argument is neither reported as unused nor as missing, if it's passed.
+The callback is called synchronously by Locale.Tr() and the others,
+so it must be fast and thread-safe. Pass nil to remove it.
+
+Nil safe. If this method is called on nil object, there is no-op.
+*/
+func (c *Client) SetOnInterpolationIssue(fn OnInterpolationIssue) {
+	if !c.isValid() {
+		return
+	}
+	var ptr unsafe.Pointer
+	if fn != nil {
+		ptr = unsafe.Pointer(&fn)
+	}
+	atomic.StorePointer(&c.onInterpIssue, ptr)
+}
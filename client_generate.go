@@ -0,0 +1,152 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+)
+
+/*
+GenerateKeyConstants writes to w a Go source file of package pkgName,
+that contains constants for all translation keys of all loaded locales, like:
+
+        const KeyMenuFileOpen = "Menu/File/Open"
+
+So you could use them instead of strings, and a compiler catches a typo
+or a removed key. It's designed to be used from go:generate step:
+
+        //go:generate go run ./cmd/genkeys
+
+where genkeys sources and loads locales
+and calls GenerateKeyConstants("i18nkeys", os.Stdout).
+
+Each key segment is converted to the PascalCase identifier part,
+non letter and non digit chars are treated as words separators
+("Menu/file-open" -> "KeyMenuFileOpen"). If two keys are converted
+to the same name, the numeric suffix is added to the latter ones
+("KeyMenuFileOpen2"). Constants are sorted by the translation keys.
+
+Returns an error if there is no loaded locales, pkgName is not a valid
+Go identifier, or w returns an error.
+*/
+func (c *Client) GenerateKeyConstants(pkgName string, w io.Writer) *ekaerr.Error {
+	const s = "Failed to generate translation keys constants. "
+
+	switch {
+
+	case !c.isValid():
+		return ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+
+	case c.getStorage() == nil:
+		return ekaerr.IllegalState.
+			New(s + "There is no loaded locales.").
+			Throw()
+
+	case !token.IsIdentifier(pkgName):
+		return ekaerr.IllegalArgument.
+			New(s + "Package name is not a valid Go identifier.").
+			AddFields("privet_package_name", pkgName).
+			Throw()
+
+	case w == nil:
+		return ekaerr.IllegalArgument.
+			New(s + "Writer is nil.").
+			Throw()
+	}
+
+	// Union of all translation keys of all loaded locales.
+
+	keysSet := make(map[string]struct{})
+	for _, loc := range c.getStorage() {
+		loc.root.applyRecursively(func(node *localeNode) {
+			for key := range node.content {
+				keysSet[node.fullKey(key)] = struct{}{}
+			}
+		})
+	}
+
+	keys := make([]string, 0, len(keysSet))
+	for key := range keysSet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+
+	b.WriteString("// Code generated by privet.GenerateKeyConstants(). DO NOT EDIT.\n\n")
+	b.WriteString("package " + pkgName + "\n\n")
+	b.WriteString("const (\n")
+
+	usedNames := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		name := generateKeyConstantName(key)
+		if _, isUsed := usedNames[name]; isUsed {
+			for i := 2; ; i++ {
+				if _, isUsed = usedNames[name + strconv.Itoa(i)]; !isUsed {
+					name += strconv.Itoa(i)
+					break
+				}
+			}
+		}
+		usedNames[name] = struct{}{}
+		b.WriteString("\t" + name + " = " + strconv.Quote(key) + "\n")
+	}
+
+	b.WriteString(")\n")
+
+	src, legacyErr := format.Source(b.Bytes())
+	if legacyErr != nil {
+		// You should never see this error, because otherwise it's a bug.
+		return ekaerr.InternalError.
+			Wrap(legacyErr, s + "Generated source is malformed. This is a bug.").
+			Throw()
+	}
+
+	if _, legacyErr = w.Write(src); legacyErr != nil {
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to write generated source.").
+			Throw()
+	}
+
+	return nil
+}
+
+/*
+generateKeyConstantName returns an exported Go identifier for the translation key.
+See GenerateKeyConstants() for more details.
+*/
+func generateKeyConstantName(key string) string {
+
+	var b strings.Builder
+	b.Grow(len(key) + 3)
+	b.WriteString("Key")
+
+	upperNext := true
+	for _, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upperNext = true
+		case upperNext:
+			b.WriteRune(unicode.ToUpper(r))
+			upperNext = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -6,8 +6,11 @@
 package privet
 
 import (
+	"strings"
 	"sync/atomic"
 	"unsafe"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
 )
 
 //goland:noinspection GoSnakeCaseUsage
@@ -74,17 +77,190 @@ func strState(v uint32) string {
 	}
 }
 
+/*
+setConfigFlag atomically stores passed C-like bool value
+to the one of Client's config variables the flag points to.
+*/
+func (_ *Client) setConfigFlag(flag *uint32, value bool) {
+	if value {
+		atomic.StoreUint32(flag, 1)
+	} else {
+		atomic.StoreUint32(flag, 0)
+	}
+}
+
+/*
+getInterpFunc returns an InterpFunc registered with the passed name
+or nil if there is no such.
+*/
+func (c *Client) getInterpFunc(name string) InterpFunc {
+	interpFuncs := (*map[string]InterpFunc)(atomic.LoadPointer(&c.interpFuncs))
+	if interpFuncs == nil {
+		return nil
+	}
+	return (*interpFuncs)[name]
+}
+
+/*
+callOnUnknownFilter calls OnUnknownFilter callback with passed arguments, if it's set.
+*/
+func (c *Client) callOnUnknownFilter(localeName, verb, filter string) {
+	if onUnknownFilter := (*OnUnknownFilter)(atomic.LoadPointer(&c.onUnknownFilter)); onUnknownFilter != nil {
+		(*onUnknownFilter)(localeName, verb, filter)
+	}
+}
+
+/*
+callOnWatchError calls OnWatchError callback with passed arguments, if it's set.
+*/
+func (c *Client) callOnWatchError(path string, err *ekaerr.Error) {
+	if onWatchError := (*OnWatchError)(atomic.LoadPointer(&c.onWatchError)); onWatchError != nil {
+		(*onWatchError)(path, err)
+	}
+}
+
+/*
+callOnInterpolationIssue calls OnInterpolationIssue callback with passed arguments, if it's set.
+*/
+func (c *Client) callOnInterpolationIssue(key string, missing, unused []string) {
+	if onInterpIssue := (*OnInterpolationIssue)(atomic.LoadPointer(&c.onInterpIssue)); onInterpIssue != nil {
+		(*onInterpIssue)(key, missing, unused)
+	}
+}
+
+/*
+renderNilArg returns a string the verb with nil argument is replaced by.
+It's the result of NilArgRenderer if it's set, or an empty string otherwise.
+*/
+func (c *Client) renderNilArg(verb string) string {
+	if nilArgRenderer := (*NilArgRenderer)(atomic.LoadPointer(&c.nilArgRenderer)); nilArgRenderer != nil {
+		return (*nilArgRenderer)(verb)
+	}
+	return ""
+}
+
+/*
+getSafePlaceholder returns a string Locale.TrSafe() returns instead of special strings.
+See SetSafePlaceholder() for more details.
+*/
+func (c *Client) getSafePlaceholder() string {
+	if placeholder := (*string)(atomic.LoadPointer(&c.safePlaceholder)); placeholder != nil {
+		return *placeholder
+	}
+	return ""
+}
+
+/*
+getArraySeparator returns a string the elements of arrays of sources are joined by.
+It's DEFAULT_ARRAY_SEPARATOR, if it's not set. See SetArraySeparator().
+*/
+func (c *Client) getArraySeparator() string {
+	if arraySeparator := (*string)(atomic.LoadPointer(&c.arraySeparator)); arraySeparator != nil {
+		return *arraySeparator
+	}
+	return DEFAULT_ARRAY_SEPARATOR
+}
+
+/*
+getKeyDelimiters returns the delimiters translation keys are split by.
+It's DEFAULT_DELIMITER, if they are not set. See SetKeyDelimiters().
+*/
+func (c *Client) getKeyDelimiters() string {
+	if keyDelimiters := (*string)(atomic.LoadPointer(&c.keyDelimiters)); keyDelimiters != nil {
+		return *keyDelimiters
+	}
+	return string(DEFAULT_DELIMITER)
+}
+
+/*
+getLoadedStorage returns the last published loadedStorage (see setStorage())
+or nil if no one locale was loaded yet. Use it instead of the getStorage()
+and getSources() pair, if both of them are required outside of loading,
+so they are taken from the same snapshot.
+*/
+func (c *Client) getLoadedStorage() *loadedStorage {
+	return (*loadedStorage)(atomic.LoadPointer(&c.storage))
+}
+
+/*
+getStorage returns a map of loaded Locales by their names.
+The returned map is never changed, Load() replaces it as a whole (see setStorage()),
+so it's a consistent snapshot that is safe to read concurrently with Load().
+nil is returned if no one locale was loaded yet.
+*/
+func (c *Client) getStorage() map[string]*Locale {
+	if storage := c.getLoadedStorage(); storage != nil {
+		return storage.locales
+	}
+	return nil
+}
+
+/*
+getSources returns the sources the loaded Locales (see getStorage())
+are constructed from, in order they are loaded, so the indexes
+of localeNode.usedSourcesIdx and localeNode.origins point to them.
+The returned slice is never changed, the same way as the map of Locales.
+nil is returned if no one locale was loaded yet.
+*/
+func (c *Client) getSources() []SourceItem {
+	if storage := c.getLoadedStorage(); storage != nil {
+		return storage.sources
+	}
+	return nil
+}
+
+/*
+setStorage atomically publishes storage as a map of loaded Locales
+along with the sources they are constructed from (see getSources()),
+so the readers never get the Locales of one Load() and the sources of another.
+Neither storage nor sources MUST be changed after. nil storage means
+there is no loaded locales.
+*/
+func (c *Client) setStorage(storage map[string]*Locale, sources []SourceItem) {
+
+	if storage == nil {
+		atomic.StorePointer(&c.storage, nil)
+		return
+	}
+
+	loaded := &loadedStorage{
+		locales:      storage,
+		sources:      sources,
+		localesTotal: uint32(len(storage)),
+	}
+	for _, loc := range storage {
+		loaded.phrasesTotal += loc.phrasesCount
+	}
+
+	atomic.StorePointer(&c.storage, unsafe.Pointer(loaded))
+}
+
+/*
+getMaxDirectoryDepth returns Config.MaxDirectoryDepth (see SetMaxDirectoryDepth())
+or _SOURCE_MAX_RECURSIVELY_DIRECTORY_SCAN, if it's not set.
+*/
+func (c *Client) getMaxDirectoryDepth() int {
+	if maxDepth := atomic.LoadUint32(&c.maxDirectoryDepth); maxDepth != 0 {
+		return int(maxDepth)
+	}
+	return _SOURCE_MAX_RECURSIVELY_DIRECTORY_SCAN
+}
+
 /*
 getDefaultLocale returns a Locale object that was marked as default locale.
+It's taken by name from the published snapshot of loaded Locales (see getStorage()),
+so it's the same snapshot other Locales are taken from,
+even if Load() (or reloading) is in progress or has just published a new one.
 
 If either no one Locale object was marked as default
 or no one locale was loaded yet, nil is returned.
 */
 func (c *Client) getDefaultLocale() *Locale {
-	if c.getState() != _LLS_READY {
+	defaultLocale := (*Locale)(atomic.LoadPointer(&c.defaultLocale))
+	if defaultLocale == nil {
 		return nil
 	}
-	return (*Locale)(atomic.LoadPointer(&c.defaultLocale))
+	return c.getStorage()[defaultLocale.name]
 }
 
 /*
@@ -100,14 +276,156 @@ getLocale returns a Locale object for the requested locale's name.
 E.g: It returns English locale's entry point if you passed "en_US" and you did load
 locale with that name.
 
+The name is normalized (see normalizeLocaleName()) if there is no Locale
+with exactly the same name, so "en-us", "EN_US", "En_Us" are the same as "en_US".
+
+If there is still no Locale, the best-effort match is performed:
+a requested name w/o script subtag matches the only loaded Locale
+of the same language and region, but with a script subtag
+(e.g: "zh_CN" -> "zh_Hans_CN", if there is no "zh_Hant_CN").
+
+The name might be an "Accept-Language" HTTP header value as is
+(e.g: "en-US,en;q=0.9,ru;q=0.8"). The first loaded Locale in the order
+of preference is returned then (see parseAcceptLanguage()).
+
+Locales are taken from the last published snapshot (see getStorage()),
+so they are available while Source(), Load() or reloading is in progress.
+
 If either Locale with the requested name is not exist,
 or no one locale was loaded yet nil is returned.
 */
 func (c *Client) getLocale(name string) *Locale {
-	if c.getState() != _LLS_READY {
+
+	storage := c.getStorage()
+	if storage == nil {
+		return nil
+	}
+
+	if loc := storage[name]; loc != nil {
+		return loc
+	}
+
+	if strings.ContainsAny(name, ",;") {
+		for _, languageRange := range parseAcceptLanguage(name) {
+			if loc := c.getLocale(languageRange); loc != nil {
+				return loc
+			}
+		}
+		return nil
+	}
+
+	language, script, region := parseLocaleName(name)
+	if language == "" {
+		return nil
+	}
+	if loc := storage[joinLocaleName(language, script, region)]; loc != nil || script != "" {
+		return loc
+	}
+
+	var match *Locale
+	for locName, loc := range storage {
+		locLanguage, locScript, locRegion := parseLocaleName(locName)
+		if locLanguage != language || locRegion != region || locScript == "" {
+			continue
+		}
+		if match != nil {
+			return nil // ambiguous
+		}
+		match = loc
+	}
+
+	return match
+}
+
+/*
+getFallbackLocales returns the loaded Locales of the fallback chain of passed Locale
+(see SetFallback()) followed by the default Locale, if Config.FallbackToDefault
+is enabled. If the chain is not set, the fallbacks declared by the Locale's metadata
+are used instead. The passed Locale itself and duplicates are excluded.
+Returns nil if there is no fallbacks.
+*/
+func (c *Client) getFallbackLocales(loc *Locale) []*Locale {
+
+	name := loc.name
+
+	var chain []string
+	if fallbacks := (*map[string][]string)(atomic.LoadPointer(&c.fallbacks)); fallbacks != nil {
+		chain = (*fallbacks)[name]
+	}
+	if len(chain) == 0 {
+		chain = loc.fallbacks
+	}
+
+	var defaultLocale *Locale
+	if atomic.LoadUint32(&c.config.FallbackToDefault) == 1 {
+		defaultLocale = c.getDefaultLocale()
+	}
+
+	if len(chain) == 0 && defaultLocale == nil {
+		return nil
+	}
+
+	locales := make([]*Locale, 0, len(chain) + 1)
+	appendUnique := func(loc *Locale) {
+		if loc == nil || loc.name == name {
+			return
+		}
+		for _, addedLoc := range locales {
+			if addedLoc == loc {
+				return
+			}
+		}
+		locales = append(locales, loc)
+	}
+
+	for _, fallback := range chain {
+		appendUnique(c.getLocale(fallback))
+	}
+	appendUnique(defaultLocale)
+
+	return locales
+}
+
+/*
+getSiblingLocale returns a loaded Locale of the same language as requested name has,
+but of another region (e.g: "pt_PT" for "pt_BR"),
+if Config.AllowSiblingLanguageFallback is enabled. Returns nil otherwise,
+or if there is no such Locale, or name is not a locale name.
+
+Locales from Config.SiblingPriority are preferred (in their order),
+otherwise the first one in lexicographical order is returned.
+*/
+func (c *Client) getSiblingLocale(name string) *Locale {
+
+	if atomic.LoadUint32(&c.config.AllowSiblingLanguageFallback) == 0 {
+		return nil
+	}
+
+	if name = normalizeLocaleName(name); !isValidLocaleName(name) {
 		return nil
 	}
-	return c.storage[name]
+
+	language := localeLanguage(name)
+	storage := c.getStorage()
+
+	if priority := (*[]string)(atomic.LoadPointer(&c.siblingPriority)); priority != nil {
+		for _, siblingName := range *priority {
+			if localeLanguage(siblingName) == language {
+				if loc := storage[siblingName]; loc != nil {
+					return loc
+				}
+			}
+		}
+	}
+
+	var sibling *Locale
+	for siblingName, loc := range storage {
+		if localeLanguage(siblingName) == language && (sibling == nil || siblingName < sibling.name) {
+			sibling = loc
+		}
+	}
+
+	return sibling
 }
 
 /*
@@ -6,7 +6,15 @@
 package privet
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
+	"unsafe"
 
 	"github.com/qioalice/ekago/v2/ekaerr"
 
@@ -14,14 +22,51 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+type (
+	/*
+	loadOptions is a snapshot of Client's config variables
+	that are related to locales loading.
+	It's taken once at the beginning of Client.load(),
+	so config changes during loading don't affect it.
+	*/
+	loadOptions struct {
+		overwrite       bool
+		continueOnError bool
+		skipInvalid     bool
+		keepKeyOrder    bool
+		preprocessor    Preprocessor
+
+		trimPhrases          bool
+		collapsePhraseSpaces bool
+
+		keyTransform      KeyTransform
+		keyTransformNodes bool
+		ignoreKeyPrefixes []string
+		keyDelimiters     string
+		arraySeparator    string
+		trace             OnTrace // nil if Config.TraceLoad is disabled
+
+		metaDataLocaleKeys  []string
+		strictMetaData      bool
+		contentResolveOrder []SourceItemType
+	}
+)
+
 var (
 	/*
-	TODO: comment
+	loadContentUnknownResolvers are decoders that are tried to decode
+	the content of SOURCE_ITEM_TYPE_CONTENT_UNKNOWN source,
+	in order of Config.ContentResolveOrder (it's the order they are declared by default).
+	The first successful one determines the content's type.
 	*/
 	loadContentUnknownResolvers = []struct{
 		Unmarshaler    func(d []byte, v interface{}) error
 		AssociatedType SourceItemType
 	}{
+		{
+			Unmarshaler: jsonUnmarshal,
+			AssociatedType: SOURCE_ITEM_TYPE_CONTENT_JSON,
+		},
 		{
 			Unmarshaler: yaml.Unmarshal,
 			AssociatedType: SOURCE_ITEM_TYPE_CONTENT_YAML,
@@ -33,6 +78,112 @@ var (
 	}
 )
 
+/*
+transformKey returns the key transformed by KeyTransform (see Client.SetKeyTransform()),
+if it's set. Node's names are transformed only if Config.KeyTransformNodes is set.
+*/
+func (o *loadOptions) transformKey(key string, isNode bool) string {
+	if o.keyTransform == nil || isNode && !o.keyTransformNodes {
+		return key
+	}
+	return o.keyTransform(key)
+}
+
+/*
+tracef passes the message formatted by fmt.Sprintf() to the OnTrace callback,
+if Config.TraceLoad is enabled (see Client.SetOnTrace()). Otherwise it's no-op.
+*/
+func (o *loadOptions) tracef(format string, args ...interface{}) {
+	if o.trace != nil {
+		o.trace(fmt.Sprintf(format, args...))
+	}
+}
+
+/*
+isIgnoredKey reports whether the key (of phrase or node) starts with
+any of Config.IgnoreKeyPrefix (see Client.SetIgnoreKeyPrefix()).
+*/
+func (o *loadOptions) isIgnoredKey(key string) bool {
+	for _, prefix := range o.ignoreKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+jsonUnmarshal is the same as json.Unmarshal() but numbers are decoded
+the same way as YAML decoder does: integers as int64 and the rest as float64,
+if v is *map[string]interface{}.
+*/
+func jsonUnmarshal(d []byte, v interface{}) error {
+
+	decoder := json.NewDecoder(bytes.NewReader(d))
+	decoder.UseNumber()
+
+	if legacyErr := decoder.Decode(v); legacyErr != nil {
+		return legacyErr
+	}
+	if _, legacyErr := decoder.Token(); legacyErr != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+
+	if m, ok := v.(*map[string]interface{}); ok {
+		jsonConvertNumbers(*m)
+	}
+
+	return nil
+}
+
+/*
+jsonConvertNumbers replaces json.Number values of m (and its nested maps and arrays)
+by int64 or float64 values. See jsonUnmarshal().
+*/
+func jsonConvertNumbers(m map[string]interface{}) {
+	for key, value := range m {
+		m[key] = jsonConvertNumber(value)
+	}
+}
+
+/*
+jsonConvertNumber returns int64 or float64 value of passed json.Number value,
+or value as is, converting json.Number values of nested maps and arrays in place.
+*/
+func jsonConvertNumber(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+
+	case json.Number:
+		if i64, legacyErr := typedValue.Int64(); legacyErr == nil {
+			return i64
+		} else if f64, legacyErr := typedValue.Float64(); legacyErr == nil {
+			return f64
+		}
+
+	case map[string]interface{}:
+		jsonConvertNumbers(typedValue)
+
+	case []interface{}:
+		for i := range typedValue {
+			typedValue[i] = jsonConvertNumber(typedValue[i])
+		}
+	}
+
+	return value
+}
+
+/*
+loadContentResolveOrderDefault returns the types of loadContentUnknownResolvers
+in order they are declared.
+*/
+func loadContentResolveOrderDefault() []SourceItemType {
+	resolveOrder := make([]SourceItemType, len(loadContentUnknownResolvers))
+	for i, contentResolver := range loadContentUnknownResolvers {
+		resolveOrder[i] = contentResolver.AssociatedType
+	}
+	return resolveOrder
+}
+
 /*
 load literally does things Client.Load() method describes.
 */
@@ -45,21 +196,30 @@ func (c *Client) load() *ekaerr.Error {
 			New(s + "Client is not valid.").
 			Throw()
 
-	case c.getState() == _LLS_READY:
-		// There was no successful Source() call before Load() one?
-		return ekaerr.IllegalState.
-			New(s + "There was no successful Source() call before.").
-			Throw()
+	case !(c.changeState(_LLS_STANDBY, _LLS_LOAD_PENDING) ||
+		c.changeState(_LLS_READY, _LLS_LOAD_PENDING)):
 
-	case !c.changeState(_LLS_STANDBY, _LLS_LOAD_PENDING):
 		// If we can't do CAS, it because of data-race.
 		// Another one Load() is called. Or Source(). No matter.
+		allowedStates := []string{
+			strState(_LLS_STANDBY),
+			strState(_LLS_READY),
+		}
+
 		return ekaerr.IllegalState.
 			New(s + "Another Source() or Load() called.").
-			AddFields("privet_allowed_states", strState(_LLS_STANDBY)).
+			AddFields("privet_allowed_states", strings.Join(allowedStates, ", ")).
 			Throw()
 	}
 
+	// There was no successful Source() call after the last successful Load().
+	// Locales are loaded already, so there is nothing to do.
+
+	if len(c.sourcesTmp) == 0 && c.getStorage() != nil {
+		c.changeStateForce(_LLS_READY)
+		return nil
+	}
+
 	// We got "lock" of c.state as _LLS_LOAD_PENDING.
 	// We need to change it to _LLS_STANDBY or _LLS_READY when this func is over
 	// depends on HOW this func is over.
@@ -73,16 +233,20 @@ func (c *Client) load() *ekaerr.Error {
 	//  - New locales has not been loaded, not nil error is returned to the caller,
 	//    AND there was no previous loaded locales.
 
+	c.reportTmp = new(LoadReport)
+
 	defer func(c *Client){
-		if c.storage != nil {
+		atomic.StorePointer(&c.report, unsafe.Pointer(c.reportTmp))
+		c.reportTmp = nil
+
+		if c.getStorage() != nil {
 			c.changeStateForce(_LLS_READY)
 		} else {
 			c.changeStateForce(_LLS_STANDBY)
 		}
 	}(c)
 
-	switch {
-	case len(c.sourcesTmp) == 0:
+	if len(c.sourcesTmp) == 0 {
 		return ekaerr.IllegalState.
 			New(s + "There is no valid sources counted yet.").
 			Throw()
@@ -91,27 +255,126 @@ func (c *Client) load() *ekaerr.Error {
 	// We don't have Client's fields initialization.
 	// So, initialize storageTmp here if it's not yet so.
 
+	storage := c.getStorage()
+
 	if c.storageTmp == nil {
-		c.storageTmp = make(map[string]*Locale)
+		c.storageTmp = make(map[string]*Locale, len(storage))
+	}
+
+	// New sources of already loaded locales must be merged into them
+	// (by translation keys, like the sources of one Load() call).
+	// Already loaded locales are still may be in use, so their copies are used.
+
+	for localeName, loadedLocale := range storage {
+		c.storageTmp[localeName] = loadedLocale.clone()
+	}
+
+	// Sources of already loaded locales go first,
+	// so indexes of sources saved in their nodes are still valid,
+	// and new sources are loaded starting from the loadedSources index.
+
+	loadedSources := len(c.getSources())
+	if loadedSources != 0 {
+		sources := make([]SourceItem, 0, loadedSources + len(c.sourcesTmp))
+		sources = append(sources, c.getSources()...)
+		c.sourcesTmp = append(sources, c.sourcesTmp...)
 	}
 
 	// We are ready to start loading.
 	// Let's go.
 
-	overwrite := atomic.LoadUint32(&c.config.OverwriteExistingKey) == 1
+	opts := c.makeLoadOptions()
+	opts.tracef("Loading %d new source(s), %d source(s) are already loaded.",
+		len(c.sourcesTmp) - loadedSources, loadedSources)
 
 	var err *ekaerr.Error
-	for i, n := 0, len(c.sourcesTmp); i < n && err == nil; i++ {
-		err = c.loadItem(i, overwrite)
+	for i := loadedSources; i < len(c.sourcesTmp) && err == nil; i++ {
+		if err = c.loadItem(i, &opts); err.IsNotNil() && opts.skipInvalid {
+			opts.tracef("Source %s: skipped, because of %s error (ID: %s).",
+				c.sourcesTmp[i].Path, err.Class().FullName(), err.ID())
+			c.reportTmp.SkippedSources = append(c.reportTmp.SkippedSources, LoadSkippedSource{
+				Path:  c.sourcesTmp[i].Path,
+				Error: err,
+			})
+			// The next source takes the place of the dropped one.
+			c.dropSourceItem(i)
+			i--
+			err = nil
+		}
 	}
 
-	// There is no necessary to hold locale's content anymore.
-	// No matter, whether sources has been loaded successfully or not.
+	if err.IsNil() {
+		opts.tracef("All sources are loaded, %d locale(s) in total.", len(c.storageTmp))
+	}
 
-	for i, n := 0, len(c.sourcesTmp); i < n; i++ {
-		c.sourcesTmp[i].content = nil
+	return c.loadComplete(err)
+}
+
+/*
+makeLoadOptions returns a snapshot of Client's config, that is used by loading.
+*/
+func (c *Client) makeLoadOptions() loadOptions {
+
+	opts := loadOptions{
+		overwrite:       atomic.LoadUint32(&c.config.OverwriteExistingKey) == 1,
+		continueOnError: atomic.LoadUint32(&c.config.ContinueOnSourceError) == 1,
+		skipInvalid:     atomic.LoadUint32(&c.config.SkipInvalidSources) == 1,
+		keepKeyOrder:    atomic.LoadUint32(&c.config.PreserveKeyOrder) == 1,
+		keyDelimiters:   c.getKeyDelimiters(),
+		arraySeparator:  c.getArraySeparator(),
+
+		trimPhrases:          atomic.LoadUint32(&c.config.TrimPhrases) == 1,
+		collapsePhraseSpaces: atomic.LoadUint32(&c.config.CollapsePhraseSpaces) == 1,
+
+		strictMetaData: atomic.LoadUint32(&c.config.StrictMetadata) == 1,
 	}
 
+	if preprocessor := (*Preprocessor)(atomic.LoadPointer(&c.preprocessor)); preprocessor != nil {
+		opts.preprocessor = *preprocessor
+	}
+
+	if keyTransform := (*KeyTransform)(atomic.LoadPointer(&c.keyTransform)); keyTransform != nil {
+		opts.keyTransform = *keyTransform
+		opts.keyTransformNodes = atomic.LoadUint32(&c.config.KeyTransformNodes) == 1
+	}
+
+	if ignoreKeyPrefixes := (*[]string)(atomic.LoadPointer(&c.ignoreKeyPrefixes)); ignoreKeyPrefixes != nil {
+		opts.ignoreKeyPrefixes = *ignoreKeyPrefixes
+	}
+
+	if trace := (*OnTrace)(atomic.LoadPointer(&c.onTrace)); trace != nil &&
+		atomic.LoadUint32(&c.config.TraceLoad) == 1 {
+
+		opts.trace = *trace
+	}
+
+	opts.contentResolveOrder = loadContentResolveOrderDefault()
+	if resolveOrder := (*[]SourceItemType)(atomic.LoadPointer(&c.contentResolveOrder)); resolveOrder != nil {
+		opts.contentResolveOrder = *resolveOrder
+	}
+
+	opts.metaDataLocaleKeys = metaDataLocaleKeysDefault
+	if localeKeys := (*[]string)(atomic.LoadPointer(&c.metaDataLocaleKeys)); localeKeys != nil {
+		opts.metaDataLocaleKeys = *localeKeys
+	}
+
+	return opts
+}
+
+/*
+loadComplete is the last step of load() and reloadSourceItems(), when the sources
+from sourcesTmp are loaded to the storageTmp (err is an error of that).
+If err is nil and loaded locales are valid, storageTmp becomes storage
+and sourcesTmp becomes sources, otherwise they are dropped.
+The content of published sources is kept, so their locales can be rebuilt
+by reloadSourceItems().
+
+Requirements:
+ - Client's state is _LLS_LOAD_PENDING.
+*/
+func (c *Client) loadComplete(err *ekaerr.Error) *ekaerr.Error {
+	const s = "Failed to load sourced locales. "
+
 	cleanupAfterFailedLoad := func(c *Client) {
 		c.sourcesTmp = c.sourcesTmp[:0]
 		c.storageTmp = nil
@@ -133,17 +396,26 @@ func (c *Client) load() *ekaerr.Error {
 	// Maybe files has been successfully parsed
 	// but there is no loaded phrases?
 
-	var phrasesCountTotal uint64
-	for _, loadedLocale := range c.storageTmp {
-		phrasesCountTotal += loadedLocale.phrasesCount
-	}
-	if phrasesCountTotal == 0 {
+	if c.reportTmp.PhrasesLoaded == 0 {
 		cleanupAfterFailedLoad(c)
 		return ekaerr.NotFound.
 			New(s + "Sources has been parsed but there is no translation phrases.").
 			Throw()
 	}
 
+	// storageTmp contains previously loaded locales (merged with the new sources)
+	// and just loaded ones, so it's a new storage.
+
+	storage := c.storageTmp
+
+	defaultLocale, err := c.checkStorage(storage)
+	if err.IsNotNil() {
+		cleanupAfterFailedLoad(c)
+		return err.
+			AddMessage(s).
+			Throw()
+	}
+
 	// OK. We are almost done.
 
 	for _, loadedLocale := range c.storageTmp {
@@ -152,20 +424,68 @@ func (c *Client) load() *ekaerr.Error {
 		})
 	}
 
-	c.storage = c.storageTmp
-	c.storageTmp = nil
+	if atomic.LoadUint32(&c.config.PruneEmptyNodes) == 1 {
+		c.pruneAll(storage)
+	}
 
-	c.sources = c.sourcesTmp
-	c.sourcesTmp = c.sourcesTmp[:0]
+	// The tokens are stored in the nodes along with the phrases,
+	// so they are published with the storage.
 
-	c.phrasesTotal = phrasesCountTotal
-	c.localesTotal = uint32(len(c.storage))
+	if atomic.LoadUint32(&c.config.CompilePhrases) == 1 {
+		compileStorage(storage)
+	}
 
-	c.setDefaultLocale(nil)
+	// sourcesTmp is published, so it's not reused anymore.
+
+	c.setStorage(storage, c.sourcesTmp)
+	c.storageTmp = nil
+	c.sourcesTmp = nil
+
+	c.setDefaultLocale(defaultLocale)
 
 	return nil
 }
 
+/*
+checkStorage does the checks of the new storage, that are done after loading
+(see checkInheritance(), checkRequiredArgs(), Config.RequireDefaultLocale),
+and returns its Locale that must be marked as default (nil if there is no such).
+
+Default locale (if any) is kept by its name.
+Otherwise, Config.AutoDefaultLocale is marked as default, if it's set.
+*/
+func (c *Client) checkStorage(storage map[string]*Locale) (*Locale, *ekaerr.Error) {
+
+	if err := checkInheritance(storage); err.IsNotNil() {
+		return nil, err.
+			Throw()
+	}
+
+	if err := checkRequiredArgs(storage); err.IsNotNil() {
+		return nil, err.
+			Throw()
+	}
+
+	defaultLocale := (*Locale)(atomic.LoadPointer(&c.defaultLocale))
+	if defaultLocale != nil {
+		defaultLocale = storage[defaultLocale.name]
+	}
+
+	if defaultLocale == nil {
+		if autoDefaultLocale := (*string)(atomic.LoadPointer(&c.autoDefaultLocale)); autoDefaultLocale != nil {
+			defaultLocale = storage[*autoDefaultLocale]
+		}
+	}
+
+	if defaultLocale == nil && atomic.LoadUint32(&c.config.RequireDefaultLocale) == 1 {
+		return nil, ekaerr.IllegalState.
+			New("There is no default locale, but it's required.").
+			Throw()
+	}
+
+	return defaultLocale, nil
+}
+
 /*
 loadItem tries to parse and then add all data from the SourceItem's locale content
 placed in sourcesTmp by passed sourceItemIdx index.
@@ -173,39 +493,120 @@ placed in sourcesTmp by passed sourceItemIdx index.
 To put it simply,
 c.sourcesTmp[sourceItemIdx] will be loaded.
 */
-func (c *Client) loadItem(sourceItemIdx int, overwrite bool) *ekaerr.Error {
+func (c *Client) loadItem(sourceItemIdx int, opts *loadOptions) *ekaerr.Error {
 	const s = "Failed to load sourced locale. "
 
 	var (
 		err        *ekaerr.Error
 		rootMap    = make(map[string]interface{})
 		sourceItem = &c.sourcesTmp[sourceItemIdx]
+		content    = sourceItem.content
+		poHeader   poHeader
 	)
 
+	opts.tracef("Source %s: loading %s.", sourceItem.Path, sourceItem.Type)
+
+	// The content of source is kept as is, because the source might be loaded
+	// again (see reloadSourceItems()), so it's transcoded and preprocessed each time.
+
+	if sourceItem.tree == nil {
+		if content, err = sourceItem.transcode(); err.IsNotNil() {
+			return err.
+				AddMessage(s).
+				AddFields("privet_source", sourceItem.Path).
+				Throw()
+		}
+	}
+
+	if opts.preprocessor != nil {
+		preprocessedContent, legacyErr := opts.preprocessor(sourceItem.Path, content)
+		if legacyErr != nil {
+			return ekaerr.IllegalFormat.
+				Wrap(legacyErr, s + "Preprocessor has failed.").
+				AddFields("privet_source", sourceItem.Path).
+				Throw()
+		}
+		content = preprocessedContent
+	}
+
+	// Keep in mind, decoders are strict about duplicated keys.
+	// Both of YAML and TOML decoders return an error with the line number(s)
+	// if the same key is defined twice or more in the same mapping (table)
+	// of one source, so they are never silently collapsed.
+	// JSON decoder is not, the last one is used.
+
 	switch sourceItem.Type {
 
-	case SOURCE_ITEM_TYPE_FILE_YAML:
-		legacyErr := yaml.Unmarshal(sourceItem.content, &rootMap)
+	// Content types are resolved once, by the first loading of the unknown content,
+	// but the same source might be loaded again.
+
+	case SOURCE_ITEM_TYPE_FILE_YAML, SOURCE_ITEM_TYPE_CONTENT_YAML:
+		legacyErr := yaml.Unmarshal(content, &rootMap)
 		err = ekaerr.IllegalFormat.
 			Wrap(legacyErr, s + "Failed to decode content using YAML decoder")
 
-	case SOURCE_ITEM_TYPE_FILE_TOML:
-		legacyErr := toml.Unmarshal(sourceItem.content, &rootMap)
+	case SOURCE_ITEM_TYPE_FILE_TOML, SOURCE_ITEM_TYPE_CONTENT_TOML:
+		legacyErr := toml.Unmarshal(content, &rootMap)
 		err = ekaerr.IllegalFormat.
 			Wrap(legacyErr, s + "Failed to decode content using TOML decoder")
 
-	case SOURCE_ITEM_TYPE_CONTENT_UNKNOWN:
+	case SOURCE_ITEM_TYPE_FILE_JSON, SOURCE_ITEM_TYPE_CONTENT_JSON:
+		legacyErr := jsonUnmarshal(content, &rootMap)
+		err = ekaerr.IllegalFormat.
+			Wrap(legacyErr, s + "Failed to decode content using JSON decoder")
+
+	case SOURCE_ITEM_TYPE_FILE_PO, SOURCE_ITEM_TYPE_CONTENT_PO:
 		var legacyErr error
-		for _, contentResolver := range loadContentUnknownResolvers {
-			legacyErr = contentResolver.Unmarshaler(sourceItem.content, &rootMap)
-			if legacyErr == nil {
-				sourceItem.Type = contentResolver.AssociatedType
+		rootMap, poHeader, legacyErr = poUnmarshal(content, opts.keyDelimiters)
+		err = ekaerr.IllegalFormat.
+			Wrap(legacyErr, s + "Failed to decode content using PO decoder")
+
+	case SOURCE_ITEM_TYPE_CONTENT_FLAT, SOURCE_ITEM_TYPE_CONTENT_MAP:
+		// Metadata is removed from the root by loadMetaData(),
+		// but the tree might be loaded again.
+		for key, value := range sourceItem.tree {
+			rootMap[key] = value
+		}
+
+	case SOURCE_ITEM_TYPE_CONTENT_UNKNOWN:
+		decodeErrs := make([]string, 0, len(opts.contentResolveOrder))
+
+		for _, typ := range opts.contentResolveOrder {
+			for _, contentResolver := range loadContentUnknownResolvers {
+				if contentResolver.AssociatedType != typ {
+					continue
+				}
+
+				// Content might be decoded partially before the error,
+				// so each decoder must use its own map.
+				rootMap = make(map[string]interface{})
+
+				legacyErr := contentResolver.Unmarshaler(content, &rootMap)
+				if legacyErr == nil && sourceItem.LocaleName == "" && !hasMetaData(rootMap) {
+					// Permissive decoder (like YAML) may decode the content
+					// of another format into garbage. But the content has no path,
+					// so it must have a metadata, if locale name is unknown.
+					// Otherwise it's a wrong decoder.
+					legacyErr = errors.New("decoded, but metadata is not found")
+				}
+
+				if legacyErr == nil {
+					opts.tracef("Source %s: format is detected as %s.", sourceItem.Path, typ)
+					sourceItem.Type = typ
+				} else {
+					decodeErrs = append(decodeErrs, typ.String() + ": " + legacyErr.Error())
+				}
+			}
+
+			if sourceItem.Type != SOURCE_ITEM_TYPE_CONTENT_UNKNOWN {
 				break
 			}
 		}
-		if legacyErr != nil {
+
+		if sourceItem.Type == SOURCE_ITEM_TYPE_CONTENT_UNKNOWN {
 			err = ekaerr.IllegalFormat.
-				New(s + "All options for decoding the byte content have failed.")
+				New(s + "All options for decoding the byte content have failed.").
+				AddFields("privet_source_decode_errors", strings.Join(decodeErrs, "; "))
 		}
 
 	default:
@@ -221,17 +622,41 @@ func (c *Client) loadItem(sourceItemIdx int, overwrite bool) *ekaerr.Error {
 	}
 
 	//goland:noinspection GoNilness
-	if err.IsNil() && atomic.LoadUint32(&c.config.SkipParseFilepath) == 0 {
+	if err.IsNil() && sourceItem.isFile() &&
+		atomic.LoadUint32(&c.config.SkipParseFilepath) == 0 {
+
 		err = sourceItem.findLocaleInFilepath().
 			AddMessage(s)
 	}
 
+	// PO file has no metadata section, but its header may declare the language.
+	// It's used only if the locale name is not found in filepath.
+
+	//goland:noinspection GoNilness
+	if err.IsNil() && poHeader.language != "" && sourceItem.LocaleName == "" {
+		sourceItem.LocaleName = poLocaleName(poHeader.language)
+		if !isValidLocaleName(sourceItem.LocaleName) {
+			err = ekaerr.IllegalFormat.
+				New(s + "PO header's language has an incorrect format. Should be: xx_YY, xx_Scrp_YY or xx.").
+				AddFields("privet_source_po_language", poHeader.language)
+		}
+	}
+
 	//goland:noinspection GoNilness
 	if err.IsNil() {
-		err = sourceItem.loadMetaData(rootMap).
+		err = sourceItem.loadMetaData(rootMap, opts.metaDataLocaleKeys, opts.strictMetaData).
 			AddMessage(s)
 	}
 
+	//goland:noinspection GoNilness
+	if err.IsNil() && (sourceItem.Type == SOURCE_ITEM_TYPE_FILE_PO ||
+		sourceItem.Type == SOURCE_ITEM_TYPE_CONTENT_PO) {
+
+		legacyErr := poResolvePlurals(rootMap, sourceItem.LocaleName, poHeader.pluralForms)
+		err = ekaerr.IllegalFormat.
+			Wrap(legacyErr, s + "Failed to map plural forms of PO messages")
+	}
+
 	//goland:noinspection GoNilness
 	if err.IsNotNil() {
 		return err.
@@ -239,13 +664,120 @@ func (c *Client) loadItem(sourceItemIdx int, overwrite bool) *ekaerr.Error {
 			Throw()
 	}
 
-	if err := c.scan(rootMap, sourceItemIdx, overwrite); err.IsNotNil() {
+	opts.tracef("Source %s: locale is resolved as %s.", sourceItem.Path, sourceItem.LocaleName)
+
+	if opts.keepKeyOrder {
+		sourceItem.keyOrder = decodeKeyOrder(sourceItem.Type, content)
+	}
+
+	phrasesLoaded := c.reportTmp.PhrasesLoaded
+
+	err = c.scan(rootMap, sourceItemIdx, opts)
+	sourceItem.keyOrder = nil
+
+	//goland:noinspection GoNilness
+	if err.IsNotNil() {
 		return err.
 			AddMessage(s).
 			AddFields("privet_source", sourceItem.Path).
 			Throw()
 	}
 
+	opts.tracef("Source %s: %d phrase(s) are stored to %s.",
+		sourceItem.Path, c.reportTmp.PhrasesLoaded - phrasesLoaded, sourceItem.LocaleName)
+
+	return nil
+}
+
+/*
+dropSourceItem removes the source placed in sourcesTmp by passed sourceItemIdx index,
+if its loading is failed, along with all language phrases from storageTmp
+that have been partially loaded from it. Also removes Locales that become empty.
+Indexes of the next sources saved in the nodes are shifted,
+so they still point to the same sources.
+
+Only contentTmp is cleared, because phrases are moved to the content
+(and the source's metadata is applied to the Locale)
+only if the whole source is loaded successfully.
+*/
+func (c *Client) dropSourceItem(sourceItemIdx int) {
+
+	c.sourcesTmp = append(c.sourcesTmp[:sourceItemIdx], c.sourcesTmp[sourceItemIdx+1:]...)
+
+	for localeName, loc := range c.storageTmp {
+		loc.root.applyRecursively(func(node *localeNode) {
+			for key := range node.contentTmp {
+				delete(node.contentTmp, key)
+			}
+			usedSourcesIdx := node.usedSourcesIdx[:0]
+			for _, usedSourceIdx := range node.usedSourcesIdx {
+				switch {
+				case usedSourceIdx < sourceItemIdx:
+					usedSourcesIdx = append(usedSourcesIdx, usedSourceIdx)
+				case usedSourceIdx > sourceItemIdx:
+					usedSourcesIdx = append(usedSourcesIdx, usedSourceIdx-1)
+				}
+			}
+			node.usedSourcesIdx = usedSourcesIdx
+			for key, originIdx := range node.origins {
+				if originIdx > sourceItemIdx {
+					node.origins[key] = originIdx - 1
+				}
+			}
+		})
+		if loc.phrasesCount == 0 {
+			delete(c.storageTmp, localeName)
+		}
+	}
+}
+
+/*
+pruneAll removes empty nodes of each Locale from passed storage.
+See Locale.Prune() for more details.
+*/
+func (c *Client) pruneAll(storage map[string]*Locale) {
+	for _, loc := range storage {
+		loc.root.prune()
+	}
+}
+
+/*
+checkInheritance ensures that each parent locale declared by metadata's
+"inherits" field of each locale from storage is loaded (presented in storage too)
+and there is no inheritance cycles (like en_US -> en_GB -> en_US).
+*/
+func checkInheritance(storage map[string]*Locale) *ekaerr.Error {
+	const s = "Failed to check locales inheritance. "
+
+	for localeName, loc := range storage {
+		visited := map[string]struct{}{localeName: {}}
+
+		for parentName := loc.inherits; parentName != ""; {
+
+			if _, isVisited := visited[parentName]; isVisited {
+				return ekaerr.IllegalFormat.
+					New(s + "Inheritance cycle detected.").
+					AddFields(
+						"privet_locale_name",     localeName,
+						"privet_locale_inherits", parentName).
+					Throw()
+			}
+
+			parent := storage[parentName]
+			if parent == nil {
+				return ekaerr.NotFound.
+					New(s + "Parent locale is not loaded.").
+					AddFields(
+						"privet_locale_name",     localeName,
+						"privet_locale_inherits", parentName).
+					Throw()
+			}
+
+			visited[parentName] = struct{}{}
+			parentName = parent.inherits
+		}
+	}
+
 	return nil
 }
 
@@ -256,7 +788,7 @@ func (c *Client) scan(
 
 	root          map[string]interface{},
 	sourceItemIdx int,
-	overwrite     bool,
+	opts          *loadOptions,
 
 ) *ekaerr.Error {
 
@@ -268,18 +800,520 @@ func (c *Client) scan(
 		c.storageTmp[sourceItem.LocaleName] = loc
 	}
 
-	if err := loc.root.scan(root, sourceItemIdx, overwrite); err.IsNotNil() {
+	// Metadata is applied to the Locale only if the whole source is scanned
+	// successfully, so the skipped source (see dropSourceItem()) leaves no trace.
+
+	switch {
+	case sourceItem.inherits == "" || loc.inherits == "":
+	case loc.inherits != sourceItem.inherits:
+		return ekaerr.IllegalFormat.
+			New("Locale's parent is ambiguous. Sources declare different parents.").
+			AddFields(
+				"privet_locale_name",       loc.name,
+				"privet_locale_inherits_1", loc.inherits,
+				"privet_locale_inherits_2", sourceItem.inherits).
+			Throw()
+	}
+
+	switch {
+	case len(sourceItem.fallbacks) == 0 || len(loc.fallbacks) == 0:
+	case strings.Join(loc.fallbacks, ",") != strings.Join(sourceItem.fallbacks, ","):
+		return ekaerr.IllegalFormat.
+			New("Locale's fallbacks are ambiguous. Sources declare different ones.").
+			AddFields(
+				"privet_locale_name",        loc.name,
+				"privet_locale_fallbacks_1", strings.Join(loc.fallbacks, ", "),
+				"privet_locale_fallbacks_2", strings.Join(sourceItem.fallbacks, ", ")).
+			Throw()
+	}
+
+	listFormat := loc.listFormat
+	if err := listFormat.merge(sourceItem.listFormat); err.IsNotNil() {
+		return err.
+			AddFields("privet_locale_name", loc.name).
+			Throw()
+	}
+
+	if err := loc.root.scan(root, sourceItemIdx, "", opts); err.IsNotNil() {
 		return err.
 			Throw()
 	}
 
+	if sourceItem.inherits != "" {
+		loc.inherits = sourceItem.inherits
+	}
+	if len(sourceItem.fallbacks) != 0 {
+		loc.fallbacks = sourceItem.fallbacks
+	}
+	loc.listFormat = listFormat
+
+	for key, requiredArgs := range sourceItem.requiredArgs {
+		if loc.requiredArgs == nil {
+			loc.requiredArgs = make(map[string][]string)
+		}
+		// Slice is not modified, it's always a new one.
+		loc.requiredArgs[key] = append(append([]string(nil), loc.requiredArgs[key]...), requiredArgs...)
+	}
+
 	loc.root.applyRecursively(func(node *localeNode) {
 		for key, value := range node.contentTmp {
+			if _, isOverwritten := node.content[key]; !isOverwritten {
+				loc.phrasesCount++
+			}
 			node.content[key] = value
-			loc.phrasesCount++
+			node.origins[key] = sourceItemIdx
+			c.reportTmp.PhrasesLoaded++
 			delete(node.contentTmp, key)
 		}
 	})
 
 	return nil
 }
+
+/*
+reloadFile literally does things Client.ReloadFile() method describes.
+*/
+func (c *Client) reloadFile(path string) *ekaerr.Error {
+	const s = "Failed to reload locale source file. "
+	switch {
+
+	case !c.isValid():
+		return ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+
+	case !c.changeState(_LLS_READY, _LLS_LOAD_PENDING):
+		// Either there is no loaded locales or there is a data-race.
+		return ekaerr.IllegalState.
+			New(s + "Locales are not loaded yet or another Source() or Load() called.").
+			AddFields("privet_allowed_states", strState(_LLS_READY)).
+			Throw()
+	}
+
+	return c.reloadFileLocked(path).
+		Throw()
+}
+
+/*
+reloadFileLocked is a part of reloadFile() that is done
+after Client's state is changed to _LLS_LOAD_PENDING. It's always _LLS_READY
+when this func is over, no matter whether the file is reloaded or not.
+
+Requirements:
+ - Client's state is _LLS_LOAD_PENDING, changed from _LLS_READY.
+*/
+func (c *Client) reloadFileLocked(path string) *ekaerr.Error {
+	const s = "Failed to reload locale source file. "
+
+	c.reportTmp = new(LoadReport)
+
+	defer func(c *Client){
+		atomic.StorePointer(&c.report, unsafe.Pointer(c.reportTmp))
+		c.reportTmp = nil
+		c.changeStateForce(_LLS_READY)
+	}(c)
+
+	if absPath, legacyErr := filepath.Abs(path); legacyErr == nil {
+		path = absPath
+	}
+
+	sources := c.getSources()
+
+	sourceItemIdx := -1
+	for i, n := 0, len(sources); i < n && sourceItemIdx == -1; i++ {
+		if sources[i].isFile() && sources[i].fsys == nil && sources[i].Path == path {
+			sourceItemIdx = i
+		}
+	}
+
+	if sourceItemIdx == -1 {
+		return ekaerr.NotFound.
+			New(s + "There is no loaded locale source file with provided path.").
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	if err := c.reloadSourceItems([]int{sourceItemIdx}); err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			Throw()
+	}
+
+	return nil
+}
+
+/*
+reload literally does things Client.Reload() method describes.
+*/
+func (c *Client) reload() *ekaerr.Error {
+	const s = "Failed to reload locale source files. "
+	switch {
+
+	case !c.isValid():
+		return ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+
+	case !c.changeState(_LLS_READY, _LLS_LOAD_PENDING):
+		// Either there is no loaded locales or there is a data-race.
+		return ekaerr.IllegalState.
+			New(s + "Locales are not loaded yet or another Source() or Load() called.").
+			AddFields("privet_allowed_states", strState(_LLS_READY)).
+			Throw()
+	}
+
+	// We got "lock" of c.state as _LLS_LOAD_PENDING.
+	// Locales are loaded already, so it's always _LLS_READY when this func is over,
+	// no matter whether the files are reloaded or not.
+
+	c.reportTmp = new(LoadReport)
+
+	defer func(c *Client){
+		atomic.StorePointer(&c.report, unsafe.Pointer(c.reportTmp))
+		c.reportTmp = nil
+		c.changeStateForce(_LLS_READY)
+	}(c)
+
+	// Only real files can be re-read. RAW data and fs.FS files are loaded again
+	// from their kept content, if their locales are rebuilt.
+
+	sources := c.getSources()
+
+	var sourceItemsIdx []int
+	for i, n := 0, len(sources); i < n; i++ {
+		if sources[i].isFile() && sources[i].fsys == nil {
+			sourceItemsIdx = append(sourceItemsIdx, i)
+		}
+	}
+
+	if len(sourceItemsIdx) == 0 {
+		return nil
+	}
+
+	if err := c.reloadSourceItems(sourceItemsIdx); err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			Throw()
+	}
+
+	return nil
+}
+
+/*
+reloadSourceItems re-reads and re-parses the loaded source files (from sources)
+with passed indexes and publishes the new storage, where the locales of these files
+are rebuilt from all their sources (see rebuildLocales()).
+Other sources are not re-read, they are loaded again from their kept content.
+If any file can not be re-read or loaded, the loaded locales are not changed.
+
+Requirements:
+ - Client's state is _LLS_LOAD_PENDING,
+ - Each passed index is an index of the real file (not fs.FS one) in sources.
+*/
+func (c *Client) reloadSourceItems(sourceItemsIdx []int) *ekaerr.Error {
+
+	// Sources counted by Source() since the last Load() are not loaded yet,
+	// so they are kept aside for the next Load().
+
+	pendingSources := c.sourcesTmp
+	c.sourcesTmp = nil
+
+	defer func(c *Client) {
+		c.sourcesTmp = pendingSources
+	}(c)
+
+	loadedSources := c.getSources()
+
+	var (
+		sources     = append(make([]SourceItem, 0, len(loadedSources)), loadedSources...)
+		isReloaded  = make(map[int]bool, len(sourceItemsIdx))
+		localeNames = make(map[string]bool, len(sourceItemsIdx))
+	)
+
+	for _, sourceItemIdx := range sourceItemsIdx {
+		path := loadedSources[sourceItemIdx].Path
+
+		var reloadedSources []SourceItem
+		if err := c.sourcePath(&reloadedSources, path, 0, false); err.IsNotNil() {
+			return err.
+				Throw()
+		}
+
+		if len(reloadedSources) != 1 {
+			return ekaerr.IllegalArgument.
+				New("Provided path is not a locale source file anymore.").
+				AddFields("privet_source_path", path).
+				Throw()
+		}
+
+		// The file is re-read with the same SourceOptions it has been sourced with.
+		reloadedSources[0].opts = loadedSources[sourceItemIdx].opts
+
+		sources[sourceItemIdx] = reloadedSources[0]
+		isReloaded[sourceItemIdx] = true
+		localeNames[loadedSources[sourceItemIdx].LocaleName] = true
+	}
+
+	opts := c.makeLoadOptions()
+
+	for {
+		isRestarted, err := c.rebuildLocales(sources, isReloaded, localeNames, &opts)
+		if !isRestarted {
+			return c.loadComplete(err)
+		}
+	}
+}
+
+/*
+rebuildLocales loads the locales with passed localeNames to the new storageTmp
+from scratch, by all their sources (that become sourcesTmp) in the order
+they have been loaded by Load(), so the same phrases are overwritten, and
+the locales' metadata (like inherits, required args) is collected again.
+Sources with indexes from isReloaded are loaded anyway, other locales are copied.
+
+If any of reloaded sources declares the locale that is not from localeNames now,
+that locale is added to them and true is returned, because it's already loaded
+partially by the previous sources, so the locales must be rebuilt again.
+
+Requirements:
+ - Client's state is _LLS_LOAD_PENDING.
+*/
+func (c *Client) rebuildLocales(
+
+	sources     []SourceItem,
+	isReloaded  map[int]bool,
+	localeNames map[string]bool,
+	opts        *loadOptions,
+
+) (bool, *ekaerr.Error) {
+
+	// Already loaded locales are still may be in use, so their copies are used.
+
+	storage := c.getStorage()
+
+	c.storageTmp = make(map[string]*Locale, len(storage))
+	for localeName, loadedLocale := range storage {
+		if !localeNames[localeName] {
+			c.storageTmp[localeName] = loadedLocale.clone()
+		}
+	}
+
+	*c.reportTmp = LoadReport{}
+	c.sourcesTmp = append(c.sourcesTmp[:0], sources...)
+
+	for i, n := 0, len(c.sourcesTmp); i < n; i++ {
+		if !isReloaded[i] && !localeNames[sources[i].LocaleName] {
+			continue
+		}
+
+		c.sourcesTmp[i].resetLoaded()
+		if err := c.loadItem(i, opts); err.IsNotNil() {
+			return false, err
+		}
+
+		if localeName := c.sourcesTmp[i].LocaleName; !localeNames[localeName] {
+			opts.tracef("Source %s: declares another locale %s now, rebuilding it too.",
+				c.sourcesTmp[i].Path, localeName)
+			localeNames[localeName] = true
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+/*
+checkRequiredArgs ensures that each phrase of each locale from storage,
+that has required args declared by metadata's "required_args" field,
+is loaded and contains the interpolation verbs of all these args.
+A verb of the arg is "{{arg}}", "{{func:arg}}", "{{arg|filter}}"
+or a dotted path starting with the arg (e.g: "{{arg.field}}").
+*/
+func checkRequiredArgs(storage map[string]*Locale) *ekaerr.Error {
+	const s = "Failed to check required args. "
+
+	for localeName, loc := range storage {
+		for key, requiredArgs := range loc.requiredArgs {
+
+			translatedPhrase, _, class := loc.lookup(key)
+			if class != "" {
+				return ekaerr.NotFound.
+					New(s + "Phrase the required args are declared for is not found.").
+					AddFields(
+						"privet_locale_name", localeName,
+						"privet_source_key",  key).
+					Throw()
+			}
+
+			verbs := phraseVerbs(translatedPhrase)
+			for i, verb := range verbs {
+				verbs[i] = verbArgName(verb)
+			}
+
+			for _, requiredArg := range requiredArgs {
+				found := false
+				for _, verb := range verbs {
+					if verb == requiredArg || strings.HasPrefix(verb, requiredArg + ".") {
+						found = true
+						break
+					}
+				}
+				if !found {
+					return ekaerr.IllegalFormat.
+						New(s + "Phrase does not contain the verb of required arg.").
+						AddFields(
+							"privet_locale_name",  localeName,
+							"privet_source_key",   key,
+							"privet_required_arg", requiredArg).
+						Throw()
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+/*
+unload literally does things Client.Unload() method describes.
+*/
+func (c *Client) unload() *ekaerr.Error {
+	const s = "Failed to unload locales. "
+	switch {
+
+	case !c.isValid():
+		return ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+
+	case !c.changeState(_LLS_READY, _LLS_LOAD_PENDING) &&
+		!c.changeState(_LLS_STANDBY, _LLS_LOAD_PENDING):
+
+		// There is a data-race. Another one Source() or Load() is called.
+		allowedStates := []string{
+			strState(_LLS_STANDBY),
+			strState(_LLS_READY),
+		}
+
+		return ekaerr.IllegalState.
+			New(s + "Another Source() or Load() called.").
+			AddFields("privet_allowed_states", strings.Join(allowedStates, ", ")).
+			Throw()
+	}
+
+	// We got "lock" of c.state as _LLS_LOAD_PENDING.
+	// It's _LLS_STANDBY, when this func is over, no matter how.
+	// Locales might be loaded, even if the state was _LLS_STANDBY,
+	// when there are sources counted by Source() and not loaded yet.
+	// These sources are kept.
+
+	if c.getStorage() == nil {
+		c.changeStateForce(_LLS_STANDBY)
+		return ekaerr.IllegalState.
+			New(s + "Locales are not loaded yet.").
+			Throw()
+	}
+
+	// Readers that got the Locales before are still may use them,
+	// so they are not destroyed, just unreachable from now.
+
+	c.setDefaultLocale(nil)
+	c.setStorage(nil, nil)
+
+	c.changeStateForce(_LLS_STANDBY)
+	return nil
+}
+
+/*
+merge literally does things Client.Merge() method describes.
+*/
+func (c *Client) merge(other *Client, overwrite bool) *ekaerr.Error {
+	const s = "Failed to merge locales of another client. "
+	switch {
+
+	case !c.isValid():
+		return ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+
+	case !other.isValid():
+		return ekaerr.IllegalArgument.
+			New(s + "Other client is nil.").
+			Throw()
+
+	case other == c:
+		return ekaerr.IllegalArgument.
+			New(s + "Client can not be merged with itself.").
+			Throw()
+
+	case !c.changeState(_LLS_READY, _LLS_LOAD_PENDING):
+		// Either there is no loaded locales or there is a data-race.
+		return ekaerr.IllegalState.
+			New(s + "Locales are not loaded yet or another Source() or Load() called.").
+			AddFields("privet_allowed_states", strState(_LLS_READY)).
+			Throw()
+	}
+
+	// We got "lock" of c.state as _LLS_LOAD_PENDING.
+	// Locales are loaded already, so it's always _LLS_READY when this func is over,
+	// no matter whether the locales are merged or not.
+
+	defer c.changeStateForce(_LLS_READY)
+
+	// Already loaded locales are still may be in use, so their copies are used,
+	// as well as the new ones for other's locales, because other's are in use too
+	// and belong to another Client.
+
+	// Other's locales and sources are taken from the same snapshot,
+	// so other's indexes of sources are valid, even if other is reloaded meanwhile.
+
+	otherLoaded := other.getLoadedStorage()
+	if otherLoaded == nil {
+		return ekaerr.IllegalState.
+			New(s + "Other client has no loaded locales.").
+			Throw()
+	}
+
+	var (
+		storage      = c.getStorage()
+		sources      = c.getSources()
+		otherStorage = otherLoaded.locales
+		otherSources = otherLoaded.sources
+	)
+
+	sourcesOffset := len(sources)
+
+	newStorage := make(map[string]*Locale, len(storage) + len(otherStorage))
+	for localeName, loadedLocale := range storage {
+		newStorage[localeName] = loadedLocale.clone()
+	}
+
+	for localeName, otherLocale := range otherStorage {
+		loc := newStorage[localeName]
+		if loc == nil {
+			loc = c.makeLocale(localeName)
+			newStorage[localeName] = loc
+		}
+		loc.merge(otherLocale, sourcesOffset, overwrite)
+	}
+
+	// The merged locales are checked the same way as the loaded ones.
+
+	defaultLocale, err := c.checkStorage(newStorage)
+	if err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			Throw()
+	}
+
+	if atomic.LoadUint32(&c.config.CompilePhrases) == 1 {
+		compileStorage(newStorage)
+	}
+
+	c.setStorage(newStorage, append(append(make([]SourceItem, 0, sourcesOffset + len(otherSources)),
+		sources...), otherSources...))
+
+	c.setDefaultLocale(defaultLocale)
+
+	return nil
+}
@@ -0,0 +1,553 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+writeTestFile writes content to the file with passed name in dir
+and sets its modification time to modTime, if it's not zero.
+Returns the path of the file.
+*/
+func writeTestFile(t *testing.T, dir, name, content string, modTime time.Time) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if legacyErr := os.WriteFile(path, []byte(content), 0o644); legacyErr != nil {
+		t.Fatal(legacyErr)
+	}
+	if !modTime.IsZero() {
+		if legacyErr := os.Chtimes(path, modTime, modTime); legacyErr != nil {
+			t.Fatal(legacyErr)
+		}
+	}
+
+	return path
+}
+
+func TestClientSkipInvalidSourceIsDropped(t *testing.T) {
+
+	var (
+		dir     = t.TempDir()
+		oldTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		badTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	paths := []string{
+		writeTestFile(t, dir, "a.en_US.yaml", "a: A\n", oldTime),
+		writeTestFile(t, dir, "b.en_US.yaml", "__metadata__:\n  inherits: ru_RU\nb: [{x: 1}]\n", badTime),
+		writeTestFile(t, dir, "c.en_US.yaml", "c: C\n", oldTime),
+	}
+
+	var c Client
+	c.SetSkipInvalidSources(true)
+
+	if err := c.Source(paths); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load, maybe the metadata of skipped source is applied")
+	}
+
+	report := c.LastLoadReport()
+	if len(report.SkippedSources) != 1 || report.SkippedSources[0].Path != paths[1] {
+		t.Errorf("SkippedSources = %v, expected %s only", report.SkippedSources, paths[1])
+	}
+
+	if stats := c.FormatStats(); stats[SOURCE_ITEM_TYPE_FILE_YAML] != 2 {
+		t.Errorf("FormatStats() = %v, expected 2 YAML files", stats)
+	}
+
+	loc := c.LC("en_US")
+	if loc.inherits != "" {
+		t.Errorf("inherits = %q, expected no parent", loc.inherits)
+	}
+	if lastModified := loc.LastModified(); !lastModified.Equal(oldTime) {
+		t.Errorf("LastModified() = %v, expected %v", lastModified, oldTime)
+	}
+
+	// The indexes of sources are shifted, so the phrases are still reloaded
+	// from their own files.
+
+	writeTestFile(t, dir, "c.en_US.yaml", "c: New C\n", time.Time{})
+	if err := c.ReloadFile(paths[2]); err.IsNotNil() {
+		t.Fatal("failed to reload")
+	}
+	for key, expected := range map[string]string{"a": "A", "c": "New C"} {
+		if translated := c.Tr("en_US", key, nil); translated != expected {
+			t.Errorf("Tr(%q) = %q, expected %q", key, translated, expected)
+		}
+	}
+}
+
+func TestClientReloadFileRebuildsLocale(t *testing.T) {
+
+	dir := t.TempDir()
+
+	paths := []string{
+		writeTestFile(t, dir, "a.en_US.yaml", "a: A1\nb: B1\n", time.Time{}),
+		writeTestFile(t, dir, "b.en_US.yaml", "a: A2\n", time.Time{}),
+		writeTestFile(t, dir, "c.en_US.yaml", "b: B3\nc: C3\n", time.Time{}),
+	}
+
+	var c Client
+	c.config.OverwriteExistingKey = 1
+
+	if err := c.Source(paths); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected map[string]string
+	}{
+		{
+			name:     "later source still overwrites reloaded one",
+			path:     paths[0],
+			content:  "a: New A1\nb: New B1\nd: D1\n",
+			expected: map[string]string{"a": "A2", "b": "B3", "c": "C3", "d": "D1"},
+		},
+		{
+			name:     "overwritten phrases are restored",
+			path:     paths[2],
+			content:  "c: New C3\n",
+			expected: map[string]string{"a": "A2", "b": "New B1", "c": "New C3", "d": "D1"},
+		},
+		{
+			name:     "edited metadata is not ambiguous",
+			path:     paths[1],
+			content:  "__metadata__:\n  fallbacks: ru_RU\na: A2\n",
+			expected: map[string]string{"a": "A2", "b": "New B1", "c": "New C3"},
+		},
+		{
+			name:     "edited metadata is reloaded again",
+			path:     paths[1],
+			content:  "__metadata__:\n  fallbacks: de_DE\na: A2\n",
+			expected: map[string]string{"a": "A2", "b": "New B1", "c": "New C3"},
+		},
+	}
+
+	for _, test := range tests {
+		writeTestFile(t, dir, filepath.Base(test.path), test.content, time.Time{})
+		if err := c.ReloadFile(test.path); err.IsNotNil() {
+			t.Fatalf("%s: failed to reload", test.name)
+		}
+		for key, expected := range test.expected {
+			if translated := c.Tr("en_US", key, nil); translated != expected {
+				t.Errorf("%s: Tr(%q) = %q, expected %q", test.name, key, translated, expected)
+			}
+		}
+	}
+
+	if fallbacks := c.LC("en_US").fallbacks; len(fallbacks) != 1 || fallbacks[0] != "de_DE" {
+		t.Errorf("fallbacks = %v, expected [de_DE]", fallbacks)
+	}
+}
+
+func TestClientReloadFileMovedToAnotherLocale(t *testing.T) {
+
+	dir := t.TempDir()
+
+	paths := []string{
+		writeTestFile(t, dir, "a.yaml", "__metadata__:\n  locale: en_US\na: A\n", time.Time{}),
+		writeTestFile(t, dir, "b.yaml", "__metadata__:\n  locale: ru_RU\nb: B\n", time.Time{}),
+		writeTestFile(t, dir, "c.yaml", "__metadata__:\n  locale: ru_RU\nc: C\n", time.Time{}),
+	}
+
+	var c Client
+
+	if err := c.Source(paths); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+
+	writeTestFile(t, dir, "a.yaml", "__metadata__:\n  locale: ru_RU\nb: New B\n", time.Time{})
+	if err := c.ReloadFile(paths[0]); err.IsNil() {
+		t.Error("ReloadFile() = nil, expected an error of duplicated key")
+	}
+
+	writeTestFile(t, dir, "a.yaml", "__metadata__:\n  locale: ru_RU\na: A\n", time.Time{})
+	if err := c.ReloadFile(paths[0]); err.IsNotNil() {
+		t.Fatal("failed to reload")
+	}
+
+	if loc := c.LC("en_US"); loc != nil {
+		t.Error("en_US is loaded, expected it's removed with its only source")
+	}
+	for key, expected := range map[string]string{"a": "A", "b": "B", "c": "C"} {
+		if translated := c.Tr("ru_RU", key, nil); translated != expected {
+			t.Errorf("Tr(%q) = %q, expected %q", key, translated, expected)
+		}
+	}
+}
+
+func TestClientReloadRequiredArgs(t *testing.T) {
+
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "en_US.yaml",
+		"__metadata__:\n  required_args:\n    greeting: [name]\ngreeting: Hi, {{name}}\n", time.Time{})
+
+	var c Client
+
+	if err := c.Source(path); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+		isFailed bool
+	}{
+		{
+			name:     "same",
+			content:  "__metadata__:\n  required_args:\n    greeting: [name]\ngreeting: Hi, {{name}}\n",
+			expected: []string{"name"},
+		},
+		{
+			name:     "changed",
+			content:  "__metadata__:\n  required_args:\n    greeting: [user]\ngreeting: Hi, {{user}}\n",
+			expected: []string{"user"},
+		},
+		{
+			name:     "removed",
+			content:  "greeting: Hi\n",
+			expected: nil,
+		},
+		{
+			name:     "verb is missing",
+			content:  "__metadata__:\n  required_args:\n    greeting: [name]\ngreeting: Hi\n",
+			expected: nil,
+			isFailed: true,
+		},
+	}
+
+	for _, test := range tests {
+		writeTestFile(t, dir, "en_US.yaml", test.content, time.Time{})
+		if err := c.Reload(); err.IsNil() == test.isFailed {
+			t.Fatalf("%s: Reload() is failed: %t, expected: %t", test.name, err.IsNotNil(), test.isFailed)
+		}
+		requiredArgs := c.LC("en_US").requiredArgs["greeting"]
+		if strings.Join(requiredArgs, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: required args = %v, expected %v", test.name, requiredArgs, test.expected)
+		}
+	}
+}
+
+func TestClientLoadRawContentType(t *testing.T) {
+
+	const (
+		tomlContent = "[__metadata__]\nlocale = \"en_US\"\n\n[Menu]\nFile = \"File\"\n"
+		yamlContent = "__metadata__:\n  locale: en_US\nTitle: Title\n"
+		jsonContent = "{\"__metadata__\": {\"locale\": \"en_US\"}, \"Name\": \"Name\"}"
+	)
+
+	tests := []struct {
+		name     string
+		source   interface{}
+		expected SourceItemType
+		key      string
+	}{
+		{"unknown toml", []byte(tomlContent), SOURCE_ITEM_TYPE_CONTENT_TOML, "Menu/File"},
+		{"raw toml", RawSource{Format: "toml", Data: []byte(tomlContent)}, SOURCE_ITEM_TYPE_CONTENT_TOML, "Menu/File"},
+		{"unknown yaml", []byte(yamlContent), SOURCE_ITEM_TYPE_CONTENT_YAML, "Title"},
+		{"unknown json", []byte(jsonContent), SOURCE_ITEM_TYPE_CONTENT_JSON, "Name"},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		path := writeTestFile(t, dir, "en_US.yaml", "Extra: Extra\n", time.Time{})
+
+		var c Client
+		if err := c.Source(test.source, path); err.IsNotNil() {
+			t.Fatalf("%s: failed to source", test.name)
+		}
+		if err := c.Load(); err.IsNotNil() {
+			t.Fatalf("%s: failed to load", test.name)
+		}
+
+		// The locale is rebuilt from all its sources, so the resolved type
+		// of the content is used to decode it again.
+
+		writeTestFile(t, dir, "en_US.yaml", "Extra: New Extra\n", time.Time{})
+		if err := c.ReloadFile(path); err.IsNotNil() {
+			t.Fatalf("%s: failed to reload", test.name)
+		}
+
+		if sourceType := c.getSources()[0].Type; sourceType != test.expected {
+			t.Errorf("%s: Type = %s, expected %s", test.name, sourceType, test.expected)
+		}
+		if !c.LC("en_US").Has(test.key) {
+			t.Errorf("%s: phrase %q is not loaded", test.name, test.key)
+		}
+	}
+}
+
+func TestClientMetadataLocaleKeys(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		keys     []string
+		content  string
+		isLoaded bool
+	}{
+		{"default", nil, "__metadata__:\n  locale: en_US\nTitle: Title\n", true},
+		{"empty names only", []string{"", "  "}, "__metadata__:\n  locale: en_US\nTitle: Title\n", true},
+		{"custom", []string{" Lang "}, "__metadata__:\n  lang: en_US\nTitle: Title\n", true},
+		{"custom overrides default", []string{"lang"}, "__metadata__:\n  locale: en_US\nTitle: Title\n", false},
+	}
+
+	for _, test := range tests {
+		var c Client
+		c.SetMetadataLocaleKeys(test.keys...)
+
+		if localeKeys := (*[]string)(c.metaDataLocaleKeys); localeKeys != nil && len(*localeKeys) == 0 {
+			t.Errorf("%s: empty set of keys is stored", test.name)
+		}
+
+		err := c.Source(RawSource{Format: "yaml", Data: []byte(test.content)})
+		if err.IsNil() {
+			err = c.Load()
+		}
+
+		if isLoaded := err.IsNil() && c.LC("en_US") != nil; isLoaded != test.isLoaded {
+			t.Errorf("%s: is loaded: %t, expected %t", test.name, isLoaded, test.isLoaded)
+		}
+	}
+}
+
+func TestClientPreserveKeyOrderWithKeyTransform(t *testing.T) {
+
+	const content = "__metadata__:\n  locale: en_US\n" +
+		"zeta: Z\nmenu_items:\n  zoo: Zoo\n  sub_menu:\n    last: Last\n    first: First\n  alpha: Alpha\nalpha: A\n"
+
+	tests := []struct {
+		name      string
+		transform bool
+		nodes     bool
+		expected  []string
+	}{
+		{"no transform", false, false, []string{
+			"zeta", "menu_items/zoo", "menu_items/sub_menu/last", "menu_items/sub_menu/first", "menu_items/alpha", "alpha",
+		}},
+		{"phrases transform", true, false, []string{
+			"ZETA", "menu_items/ZOO", "menu_items/sub_menu/LAST", "menu_items/sub_menu/FIRST", "menu_items/ALPHA", "ALPHA",
+		}},
+		{"nodes transform", true, true, []string{
+			"ZETA", "MENU_ITEMS/ZOO", "MENU_ITEMS/SUB_MENU/LAST", "MENU_ITEMS/SUB_MENU/FIRST", "MENU_ITEMS/ALPHA", "ALPHA",
+		}},
+	}
+
+	for _, test := range tests {
+		var c Client
+		c.SetPreserveKeyOrder(true)
+		if test.transform {
+			c.SetKeyTransform(strings.ToUpper)
+		}
+		c.SetKeyTransformNodes(test.nodes)
+
+		if err := c.Source(RawSource{Format: "yaml", Data: []byte(content)}); err.IsNotNil() {
+			t.Fatalf("%s: failed to source", test.name)
+		}
+		if err := c.Load(); err.IsNotNil() {
+			t.Fatalf("%s: failed to load", test.name)
+		}
+
+		if keys := c.LC("en_US").Keys(); strings.Join(keys, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: keys %v, expected %v", test.name, keys, test.expected)
+		}
+	}
+}
+
+func TestClientIncrementalLoad(t *testing.T) {
+
+	dir := t.TempDir()
+
+	var c Client
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		locales  []string
+		expected map[string]map[string]string
+	}{
+		{
+			name:     "first load",
+			file:     "core.en_US.yaml",
+			content:  "title: Title\nok: OK\n",
+			locales:  []string{"en_US"},
+			expected: map[string]map[string]string{"en_US": {"title": "Title", "ok": "OK"}},
+		},
+		{
+			name:    "new locale",
+			file:    "core.ru_RU.yaml",
+			content: "title: Заголовок\n",
+			locales: []string{"en_US", "ru_RU"},
+			expected: map[string]map[string]string{
+				"en_US": {"title": "Title", "ok": "OK"},
+				"ru_RU": {"title": "Заголовок"},
+			},
+		},
+		{
+			name:    "new phrases of loaded locale",
+			file:    "plugin.en_US.yaml",
+			content: "plugin: Plugin\n",
+			locales: []string{"en_US", "ru_RU"},
+			expected: map[string]map[string]string{
+				"en_US": {"title": "Title", "ok": "OK", "plugin": "Plugin"},
+				"ru_RU": {"title": "Заголовок"},
+			},
+		},
+		{
+			name:    "another new locale",
+			file:    "plugin.de_DE.yaml",
+			content: "plugin: Erweiterung\n",
+			locales: []string{"de_DE", "en_US", "ru_RU"},
+			expected: map[string]map[string]string{
+				"de_DE": {"plugin": "Erweiterung"},
+				"en_US": {"title": "Title", "ok": "OK", "plugin": "Plugin"},
+				"ru_RU": {"title": "Заголовок"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		path := writeTestFile(t, dir, test.file, test.content, time.Time{})
+
+		isLoaded := c.getStorage() != nil
+
+		if err := c.Source(path); err.IsNotNil() {
+			t.Fatalf("%s: failed to source", test.name)
+		}
+
+		// Already loaded locales must be available until the next Load(),
+		// and reloading them must not drop the new pending sources.
+
+		if isLoaded {
+			if c.LC("en_US") == nil {
+				t.Errorf("%s: loaded locale is not available before Load()", test.name)
+			}
+			if err := c.Reload(); err.IsNotNil() {
+				t.Fatalf("%s: failed to reload before Load()", test.name)
+			}
+		}
+
+		if err := c.Load(); err.IsNotNil() {
+			t.Fatalf("%s: failed to load", test.name)
+		}
+
+		localeNames := make([]string, 0, len(c.getStorage()))
+		for localeName := range c.getStorage() {
+			localeNames = append(localeNames, localeName)
+		}
+		sort.Strings(localeNames)
+
+		if strings.Join(localeNames, ",") != strings.Join(test.locales, ",") {
+			t.Errorf("%s: locales %v, expected %v", test.name, localeNames, test.locales)
+		}
+		for localeName, phrases := range test.expected {
+			for key, expected := range phrases {
+				if translated := c.Tr(localeName, key, nil); translated != expected {
+					t.Errorf("%s: %s: Tr(%q) = %q, expected %q",
+						test.name, localeName, key, translated, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestClientIncrementalLoadKeepsStorage(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		overwrite bool
+		isFailed  bool
+		expected  map[string]map[string]string
+	}{
+		{
+			name:    "another locale",
+			file:    "b.ru_RU.yaml",
+			content: "title: Заголовок\n",
+			expected: map[string]map[string]string{
+				"en_US": {"title": "Title", "ok": "OK"},
+				"ru_RU": {"title": "Заголовок"},
+			},
+		},
+		{
+			name:      "same locale, overwrite",
+			file:      "b.en_US.yaml",
+			content:   "title: New Title\n",
+			overwrite: true,
+			expected: map[string]map[string]string{
+				"en_US": {"title": "New Title", "ok": "OK"},
+			},
+		},
+		{
+			name:     "invalid source",
+			file:     "b.ru_RU.yaml",
+			content:  "title: [\n",
+			isFailed: true,
+			expected: map[string]map[string]string{
+				"en_US": {"title": "Title", "ok": "OK"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+
+		var c Client
+		if test.overwrite {
+			c.config.OverwriteExistingKey = 1
+		}
+
+		if err := c.Source(writeTestFile(t, dir, "a.en_US.yaml", "title: Title\nok: OK\n", time.Time{})); err.IsNotNil() {
+			t.Fatalf("%s: failed to source A", test.name)
+		}
+		if err := c.Load(); err.IsNotNil() {
+			t.Fatalf("%s: failed to load A", test.name)
+		}
+
+		if err := c.Source(writeTestFile(t, dir, test.file, test.content, time.Time{})); err.IsNotNil() {
+			t.Fatalf("%s: failed to source B", test.name)
+		}
+		if err := c.Load(); err.IsNotNil() != test.isFailed {
+			t.Errorf("%s: Load() is failed: %t, expected %t", test.name, err.IsNotNil(), test.isFailed)
+		}
+
+		if len(c.getStorage()) != len(test.expected) {
+			t.Errorf("%s: %d locales, expected %d", test.name, len(c.getStorage()), len(test.expected))
+		}
+		for localeName, phrases := range test.expected {
+			for key, expected := range phrases {
+				if translated := c.Tr(localeName, key, nil); translated != expected {
+					t.Errorf("%s: %s: Tr(%q) = %q, expected %q",
+						test.name, localeName, key, translated, expected)
+				}
+			}
+		}
+	}
+}
@@ -9,13 +9,19 @@ import (
 	"bytes"
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/qioalice/ekago/v2/ekaerr"
 	"github.com/qioalice/ekago/v2/ekaunsafe"
@@ -26,13 +32,49 @@ import (
 //goland:noinspection GoSnakeCaseUsage
 const (
 	/*
-		Source() func and its private part, a sourceString() may scan a directory
+		Source() func and its private part, a sourcePath() may scan a directory
 		you specify recursively,
 		meaning that if an original directory has a subdirectory(ies),
 		it will be scanned also and so on.
-		Up to this value.
+		Up to this value, if Config.MaxDirectoryDepth is not set.
 	*/
 	_SOURCE_MAX_RECURSIVELY_DIRECTORY_SCAN = 16
+
+	/*
+	_PACKAGE_PATH is used to skip this package's frames of call stack
+	looking for a caller of Source().
+	*/
+	_PACKAGE_PATH = "github.com/qioalice/privet/v2"
+)
+
+type (
+	/*
+	sourceFlatArg is an argument of source(), that is passed by Client.SourceFlat().
+	It's unexported, so it can not be passed to the Source() by the caller.
+	*/
+	sourceFlatArg struct {
+		localeName string
+		phrases    map[string]string
+		delimiter  byte
+	}
+
+	/*
+	sourceMapArg is an argument of source(), that is passed by Client.AddLocale().
+	It's unexported, so it can not be passed to the Source() by the caller.
+	*/
+	sourceMapArg struct {
+		localeName string
+		content    map[string]interface{}
+	}
+
+	/*
+	sourceDirArg is an argument of source(), that is passed by Client.SourceDir().
+	It's unexported, so it can not be passed to the Source() by the caller.
+	*/
+	sourceDirArg struct {
+		path      string
+		recursive bool
+	}
 )
 
 /*
@@ -44,7 +86,7 @@ but this source() method wants []interface{}.
 Thus, calling source(args) as an once statement of Source() does not lead
 to unnecessary copying. So for package level's Source() function.
 */
-func (c *Client) source(args []interface{}) *ekaerr.Error {
+func (c *Client) source(args []interface{}, opts *SourceOptions) *ekaerr.Error {
 	const s = "Failed to count one or many locale sources. "
 	switch {
 
@@ -75,19 +117,11 @@ func (c *Client) source(args []interface{}) *ekaerr.Error {
 
 	// We got "lock" of c.state as _LLS_SOURCE_PENDING.
 	// We need to change it to _LLS_STANDBY or _LLS_READY when this func is over
-	// depends on HOW this func is over.
-	//
-	// _LLS_STANDBY, when:
-	//  - All new sources has been counted, nil is returned to the caller;
-	//  - New sources has not been counted, not nil error is returned to the caller,
-	//    AND there was already counted NEW sources (previous call of Source()).
-	//
-	// _LLS_READY, when:
-	//  - New sources has not been counted, not nil error is returned to the caller,
-	//    AND there was NO already counted NEW sources (was no previous calls of Source()),
-	//    AND there was previous successful call of Load().
+	// depends on whether there are loaded locales, no matter how this func is over.
+	// Locales that are loaded already are kept available until the next Load()
+	// (incremental loading), load() decides whether there are new sources to load.
 	defer func(c *Client){
-		if len(c.sourcesTmp) == 0 && c.storage != nil {
+		if c.getStorage() != nil {
 			c.changeStateForce(_LLS_READY)
 		} else {
 			c.changeStateForce(_LLS_STANDBY)
@@ -105,12 +139,12 @@ func (c *Client) source(args []interface{}) *ekaerr.Error {
 		switch argType := reflect2.TypeOf(arg); argType.RType() {
 
 		case ekaunsafe.RTypeString():
-			err = c.sourceString(&sources, arg.(string), 0)
+			err = c.sourceString(&sources, arg.(string), true)
 
 		case ekaunsafe.RTypeStringArray():
 			arr := arg.([]string)
 			for i, n := 0, len(arr); i < n && err.IsNil(); i ++ {
-				err = c.sourceString(&sources, arr[i], 0)
+				err = c.sourceString(&sources, arr[i], true)
 			}
 
 		case ekaunsafe.RTypeBytes():
@@ -123,6 +157,34 @@ func (c *Client) source(args []interface{}) *ekaerr.Error {
 			}
 
 		default:
+			if f, ok := arg.(fs.File); ok {
+				err = c.sourceFile(&sources, f)
+				break
+			}
+			if fsys, ok := arg.(fs.FS); ok {
+				err = c.sourceFS(&sources, fsys)
+				break
+			}
+			if flat, ok := arg.(sourceFlatArg); ok {
+				err = c.sourceFlat(&sources, flat)
+				break
+			}
+			if dir, ok := arg.(sourceDirArg); ok {
+				err = c.sourceString(&sources, dir.path, dir.recursive)
+				break
+			}
+			if m, ok := arg.(sourceMapArg); ok {
+				err = c.sourceMap(&sources, m)
+				break
+			}
+			if rs, ok := arg.(RawSource); ok {
+				err = c.sourceRaw(&sources, rs)
+				break
+			}
+			if r, ok := arg.(io.Reader); ok {
+				err = c.sourceReader(&sources, r)
+				break
+			}
 			return ekaerr.IllegalArgument.
 				New(s + "Unexpected type of source.").
 				AddFields("privet_source_type", argType.String()).
@@ -196,6 +258,17 @@ func (c *Client) source(args []interface{}) *ekaerr.Error {
 			Throw()
 	}
 
+	if opts != nil {
+		if err := opts.validate(); err.IsNotNil() {
+			return err.
+				AddMessage(s).
+				Throw()
+		}
+		for i := range sources {
+			sources[i].opts = *opts
+		}
+	}
+
 	if len(c.sourcesTmp) != 0 {
 		c.sourcesTmp = append(c.sourcesTmp, sources...)
 	} else {
@@ -206,29 +279,17 @@ func (c *Client) source(args []interface{}) *ekaerr.Error {
 }
 
 /*
-sourceString tries to treat s as a path to file or directory.
-The logic depends on whether it's a file or directory.
-
-File.
-If s is a filepath, and the file can be used as a locale source
-(file is exist, access granted, file is not empty, file is valid) then it's OK.
-A new _SourceItem for that file is created and placed into dest.
-Argument deep is ignored for that case.
-
-Directory.
-If s is a path to the directory, the list of files and included directories
-will be created, and sourceString() will be called recursively for each that item.
-In that case deep is increased at the each recursive iteration,
-until _SOURCE_MAX_RECURSIVELY_DIRECTORY_SCAN. When max is reached, error is returned.
-For all included directories, sourceString() is also called recursively.
-For all found locale files a new _SourceItem objects will be created and placed
-into dest.
-Caller must call sourceString() with deep == 0.
-
-There is no check or any validation of file's content.
-It will be validated at the Load() call (and its internal parts).
+sourceString tries to treat s as a path to file or directory,
+or as a glob pattern (see filepath.Match() for syntax), if it contains
+any of glob meta characters: "*", "?", "[".
+
+The path (or pattern) is converted to the absolute one, if it's relative
+(also paths starting with "~" are supported).
+Then sourcePath() is called for the path, or for each path the pattern matches.
+It's an error if the pattern matches no path.
+recursive is passed to sourcePath() as is.
 */
-func (c *Client) sourceString(dest *[]SourceItem, source string, deep int) *ekaerr.Error {
+func (c *Client) sourceString(dest *[]SourceItem, source string, recursive bool) *ekaerr.Error {
 	const s = "Failed to analyse provided path as a locale source. "
 
 	if source = strings.TrimSpace(source); source == "" {
@@ -269,10 +330,75 @@ func (c *Client) sourceString(dest *[]SourceItem, source string, deep int) *ekae
 		}
 	}
 
-	if deep == 0 {
-		source = filepath.Clean(source)
+	source = filepath.Clean(source)
+
+	if !strings.ContainsAny(source, "*?[") {
+		return c.sourcePath(dest, source, 0, recursive)
 	}
-	
+
+	matches, legacyErr := filepath.Glob(source)
+	switch {
+
+	case legacyErr != nil:
+		return ekaerr.IllegalArgument.
+			Wrap(legacyErr, s + "Path is a malformed glob pattern.").
+			AddFields("privet_source_pattern", source).
+			Throw()
+
+	case len(matches) == 0:
+		return ekaerr.NotFound.
+			New(s + "Path is a glob pattern that matches no path.").
+			AddFields("privet_source_pattern", source).
+			Throw()
+	}
+
+	for _, match := range matches {
+		if err := c.sourcePath(dest, match, 0, recursive); err.IsNotNil() {
+			return err.
+				AddFields("privet_source_pattern", source).
+				Throw()
+		}
+	}
+
+	return nil
+}
+
+/*
+sourcePath treats source as an absolute path to file or directory.
+The logic depends on whether it's a file or directory.
+
+File.
+If source is a filepath, and the file can be used as a locale source
+(file is exist, access granted, file is not empty, file is valid) then it's OK.
+A new _SourceItem for that file is created and placed into dest.
+Argument deep is ignored for that case.
+
+Directory.
+If source is a path to the directory, the list of files and included directories
+will be created, and sourcePath() will be called recursively for each that item.
+In that case deep is increased at the each recursive iteration,
+until Config.MaxDirectoryDepth (see Client.getMaxDirectoryDepth()).
+When max is reached, error is returned.
+For all included directories, sourcePath() is also called recursively.
+For all found locale files a new _SourceItem objects will be created and placed
+into dest.
+If recursive is false, included directories are ignored,
+so only files directly in the directory are used.
+Caller must call sourcePath() with deep == 0.
+
+There is no check or any validation of file's content.
+It will be validated at the Load() call (and its internal parts).
+*/
+func (c *Client) sourcePath(
+
+	dest      *[]SourceItem,
+	source    string,
+	deep      int,
+	recursive bool,
+
+) *ekaerr.Error {
+	const s = "Failed to analyse provided path as a locale source. "
+
 	var (
 		f         *os.File
 		fi        os.FileInfo
@@ -307,72 +433,59 @@ func (c *Client) sourceString(dest *[]SourceItem, source string, deep int) *ekae
 
 		// Ignore files that has an unsupported extension.
 
-		ext := strings.ToLower(filepath.Ext(source))
-		if ext != "" {
-			ext = ext[1:]
-		}
-
-		switch ext {
-		case "toml", "yml", "yaml", "json":
-			break
-		case "":
-			fallthrough
-		default:
+		typ, isSupported := sourceItemTypeByExt(source)
+		if !isSupported {
+			//goland:noinspection GoUnhandledErrorResult
+			f.Close()
 			return nil
 		}
 
-		h := md5.New()
-
-		// We don't have Client's fields initialization.
-		// So, initialize buf here if it's not yet so.
-		if c.buf.Cap() == 0 {
-			c.buf.Grow(64 * 1024)
+		if err := c.sourceCheckSize(fi.Size()); err.IsNotNil() {
+			//goland:noinspection GoUnhandledErrorResult
+			f.Close()
+			return err.
+				AddMessage(s).
+				AddFields("privet_source_path", source).
+				Throw()
 		}
-		c.buf.Reset()
 
-		// We will:
-		//  - read file storing its data to the RAM
-		//  - calculate MD5 hash sum
-		// at the one iteration, chunk by chunk.
-		mw := io.MultiWriter(h, &c.buf)
+		var content, md5sum []byte
+		content, md5sum, legacyErr = c.sourceRead(f)
+
+		//goland:noinspection GoUnhandledErrorResult
+		f.Close()
 
-		if _, legacyErr = io.Copy(mw, f); legacyErr != nil {
+		if legacyErr != nil {
 			return ekaerr.DataUnavailable.
 				Wrap(legacyErr, s + "Failed to read file and calculate its MD5 hash sum.").
 				AddFields("privet_source_path", source).
 				Throw()
 		}
 
-		//goland:noinspection GoUnhandledErrorResult
-		f.Close()
-
-		md5sum := h.Sum(nil)
-		b := append([]byte(nil), c.buf.Bytes()...)
+		// File's size might be unknown (e.g: device files),
+		// so the read content must be checked too.
 
-		switch ext {
-		case "yml", "yaml", "json":
-			c.sourceApprove(dest, SOURCE_ITEM_TYPE_FILE_YAML, source, b, md5sum)
-		case "toml":
-			c.sourceApprove(dest, SOURCE_ITEM_TYPE_FILE_TOML, source, b, md5sum)
-		default:
-			// You should never see this error, because otherwise it's a bug.
-			return ekaerr.InternalError.
-				New(s + "Unexpected extension of sourced document. This is a bug.").
+		if err := c.sourceCheckSize(int64(len(content))); err.IsNotNil() {
+			return err.
+				AddMessage(s).
 				AddFields("privet_source_path", source).
 				Throw()
 		}
 
+		c.sourceApprove(dest, typ, source, content, md5sum, fi.ModTime())
 		return nil
 	}
 
 	// Ok, it's directory.
 
-	if deep == _SOURCE_MAX_RECURSIVELY_DIRECTORY_SCAN {
+	if maxDepth := c.getMaxDirectoryDepth(); deep >= maxDepth {
 		//goland:noinspection GoUnhandledErrorResult
 		f.Close()
 		return ekaerr.DataUnavailable.
 			New(s + "Provided path contains too much nested directories.").
-			AddFields("privet_source_path", source).
+			AddFields(
+				"privet_source_path",         source,
+				"privet_max_directory_depth", maxDepth).
 			Throw()
 	}
 
@@ -390,11 +503,15 @@ func (c *Client) sourceString(dest *[]SourceItem, source string, deep int) *ekae
 
 	for _, fi := range fis {
 
+		if fi.IsDir() && !recursive {
+			continue
+		}
+
 		// Before we gonna do a recursive call we need to construct full absolute path
 		// to each included item in the current directory under processing.
 		source := filepath.Join(source, fi.Name())
 
-		if err := c.sourceString(dest, source, deep+1); err.IsNotNil() {
+		if err := c.sourcePath(dest, source, deep+1, recursive); err.IsNotNil() {
 			return err.
 				Throw()
 		}
@@ -403,6 +520,129 @@ func (c *Client) sourceString(dest *[]SourceItem, source string, deep int) *ekae
 	return nil
 }
 
+/*
+sourceFS walks over passed file system (e.g: embed.FS) recursively
+starting from its root, the same way as sourcePath() does for the directories,
+creating a new _SourceItem for each file with a supported extension
+and placing them into dest.
+
+Paths of the files are their paths inside fsys (with OS's path separator),
+so the locale name could be found in them the same way as for a real path.
+Such _SourceItem s are not the real files, so they can not be reloaded
+or verified (see Client.ReloadFile(), Client.VerifySources()).
+*/
+func (c *Client) sourceFS(dest *[]SourceItem, fsys fs.FS) *ekaerr.Error {
+	const s = "Failed to analyse provided file system as a locale source. "
+
+	var (
+		err      *ekaerr.Error
+		errStop  = errors.New("stop walking") // is never returned to the caller
+		maxDepth = c.getMaxDirectoryDepth()
+	)
+
+	legacyErr := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, legacyErr error) error {
+
+		switch {
+
+		case legacyErr != nil:
+			err = ekaerr.DataUnavailable.
+				Wrap(legacyErr, s + "Failed to scan a directory.").
+				AddFields("privet_source_path", path)
+
+		case d.IsDir() && path != "." && strings.Count(path, "/") + 1 >= maxDepth:
+			err = ekaerr.DataUnavailable.
+				New(s + "Provided file system contains too much nested directories.").
+				AddFields(
+					"privet_source_path",         path,
+					"privet_max_directory_depth", maxDepth)
+
+		case d.IsDir():
+			return nil
+
+		default:
+			err = c.sourceFSFile(dest, fsys, path)
+		}
+
+		if err.IsNotNil() {
+			return errStop
+		}
+		return nil
+	})
+
+	switch {
+
+	case err.IsNotNil():
+		return err.
+			Throw()
+
+	case legacyErr != nil:
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to scan a root directory.").
+			Throw()
+	}
+
+	return nil
+}
+
+/*
+sourceFSFile is a part of sourceFS() that reads the file by its path inside fsys
+and creates a new _SourceItem for it, if its extension is supported.
+*/
+func (c *Client) sourceFSFile(dest *[]SourceItem, fsys fs.FS, path string) *ekaerr.Error {
+	const s = "Failed to analyse provided file system as a locale source. "
+
+	typ, isSupported := sourceItemTypeByExt(path)
+	if !isSupported {
+		return nil
+	}
+
+	f, legacyErr := fsys.Open(path)
+	if legacyErr != nil {
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to open file.").
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	fi, legacyErr := f.Stat()
+	if legacyErr != nil {
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to get file's stat.").
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	if err := c.sourceCheckSize(fi.Size()); err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	content, md5sum, legacyErr := c.sourceRead(f)
+	if legacyErr != nil {
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to read file and calculate its MD5 hash sum.").
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	if err := c.sourceCheckSize(int64(len(content))); err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	c.sourceApprove(dest, typ, filepath.FromSlash(path), content, md5sum, fi.ModTime())
+	(*dest)[len(*dest)-1].fsys = fsys
+
+	return nil
+}
+
 /*
 sourceBytes creates a new _SourceItem for passed bytearray if it's not empty
 and placed into dest.
@@ -412,12 +652,7 @@ It will be validated at the Load() call (and its internal parts).
 func (c *Client) sourceBytes(dest *[]SourceItem, b []byte) *ekaerr.Error {
 	const s = "Failed to analyse provided RAW data as a locale source. "
 
-	_, file, lineNumber, ok := runtime.Caller(2)
-	if ok && file != "" {
-		file = ":" + strconv.Itoa(lineNumber)
-	} else {
-		file = "Source undefined. Failed to extract caller information."
-	}
+	file := sourceCaller()
 
 	if len(b) == 0 {
 		return ekaerr.IllegalFormat.
@@ -437,19 +672,527 @@ func (c *Client) sourceBytes(dest *[]SourceItem, b []byte) *ekaerr.Error {
 
 	md5sum := h.Sum(nil)
 
-	c.sourceApprove(dest, SOURCE_ITEM_TYPE_CONTENT_UNKNOWN, file, b, md5sum)
+	c.sourceApprove(dest, SOURCE_ITEM_TYPE_CONTENT_UNKNOWN, file, b, md5sum, time.Time{})
+	return nil
+}
+
+/*
+sourceReader is the same as sourceBytes() but reads the content from r
+till EOF first (see sourceRead()). r is not closed, even if it's io.ReadCloser.
+*/
+func (c *Client) sourceReader(dest *[]SourceItem, r io.Reader) *ekaerr.Error {
+	const s = "Failed to analyse provided reader as a locale source. "
+
+	file := sourceCaller()
+
+	content, md5sum, legacyErr := c.sourceRead(r)
+	if legacyErr != nil {
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to read data and calculate its MD5 hash sum.").
+			AddFields("privet_source_path", file).
+			Throw()
+	}
+
+	if err := c.sourceCheckSize(int64(len(content))); err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			AddFields("privet_source_path", file).
+			Throw()
+	}
+
+	if len(content) == 0 {
+		return ekaerr.IllegalFormat.
+			New(s + "Empty data.").
+			AddFields("privet_source_path", file).
+			Throw()
+	}
+
+	c.sourceApprove(dest, SOURCE_ITEM_TYPE_CONTENT_UNKNOWN, file, content, md5sum, time.Time{})
+	return nil
+}
+
+/*
+sourceRaw is the same as sourceBytes() but creates a _SourceItem
+of the type of RawSource's format (see RawSource.sourceItemType())
+with the RawSource's locale name, if they are provided.
+*/
+func (c *Client) sourceRaw(dest *[]SourceItem, rs RawSource) *ekaerr.Error {
+	const s = "Failed to analyse provided RAW data as a locale source. "
+
+	typ, isSupported := rs.sourceItemType()
+	localeName := normalizeLocaleName(rs.Name)
+
+	switch {
+
+	case !isSupported:
+		return ekaerr.IllegalArgument.
+			New(s + "Format is not supported. Should be: yaml, toml, json.").
+			AddFields(
+				"privet_source_path",   sourceCaller(),
+				"privet_source_format", rs.Format).
+			Throw()
+
+	case localeName != "" && !isValidLocaleName(localeName):
+		return ekaerr.IllegalArgument.
+			New(s + "Locale name has an incorrect format. Should be: xx_YY, xx_Scrp_YY or xx.").
+			AddFields(
+				"privet_source_path", sourceCaller(),
+				"privet_locale_name", rs.Name).
+			Throw()
+	}
+
+	if err := c.sourceBytes(dest, rs.Data); err.IsNotNil() {
+		return err.
+			Throw()
+	}
+
+	sourceItem := &(*dest)[len(*dest)-1]
+	sourceItem.Type = typ
+	sourceItem.LocaleName = localeName
+	sourceItem.sourcedName = localeName
+
+	return nil
+}
+
+/*
+sourceFlat creates a new SourceItem of SOURCE_ITEM_TYPE_CONTENT_FLAT type
+for passed flat map (see Client.SourceFlat()), converting it to the nested map
+right away, so all errors are reported by the Source() call.
+Content of SourceItem is a canonical representation of flat map,
+it's used to calculate MD5 hash sum only.
+*/
+func (c *Client) sourceFlat(dest *[]SourceItem, flat sourceFlatArg) *ekaerr.Error {
+	const s = "Failed to analyse provided flat map as a locale source. "
+
+	file := sourceCaller()
+
+	switch localeName := normalizeLocaleName(flat.localeName); {
+
+	case !isValidLocaleName(localeName):
+		return ekaerr.IllegalArgument.
+			New(s + "Locale name has an incorrect format. Should be: xx_YY, xx_Scrp_YY or xx.").
+			AddFields(
+				"privet_source_path", file,
+				"privet_locale_name", flat.localeName).
+			Throw()
+
+	case len(flat.phrases) == 0:
+		return ekaerr.IllegalFormat.
+			New(s + "Flat map is empty.").
+			AddFields("privet_source_path", file).
+			Throw()
+
+	default:
+		flat.localeName = localeName
+	}
+
+	keys := make([]string, 0, len(flat.phrases))
+	for key := range flat.phrases {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		tree    = make(map[string]interface{})
+		content bytes.Buffer
+	)
+
+	content.WriteString(flat.localeName)
+	content.WriteByte(0)
+
+	for _, key := range keys {
+		segments := strings.Split(key, string(flat.delimiter))
+
+		node := tree
+		for i, n := 0, len(segments); i < n; i++ {
+			var isNode bool
+
+			switch value, isExist := node[segments[i]]; {
+
+			case segments[i] == "":
+				return ekaerr.IllegalFormat.
+					New(s + "Key has an empty segment.").
+					AddFields(
+						"privet_source_path", file,
+						"privet_source_key",  key).
+					Throw()
+
+			case i == n-1 && !isExist:
+				node[segments[i]] = flat.phrases[key]
+				continue
+
+			case i < n-1 && !isExist:
+				subNode := make(map[string]interface{})
+				node[segments[i]], node = subNode, subNode
+				continue
+
+			case i < n-1:
+				node, isNode = value.(map[string]interface{})
+			}
+
+			if !isNode {
+				return ekaerr.IllegalFormat.
+					New(s + "Key is both of a phrase and a node of other keys.").
+					AddFields(
+						"privet_source_path", file,
+						"privet_source_key",  key).
+					Throw()
+			}
+		}
+
+		content.WriteString(key)
+		content.WriteByte(0)
+		content.WriteString(flat.phrases[key])
+		content.WriteByte(0)
+	}
+
+	md5sum := md5.Sum(content.Bytes())
+
+	c.sourceApprove(dest, SOURCE_ITEM_TYPE_CONTENT_FLAT, file, content.Bytes(), md5sum[:], time.Time{})
+
+	sourceItem := &(*dest)[len(*dest)-1]
+	sourceItem.LocaleName = flat.localeName
+	sourceItem.sourcedName = flat.localeName
+	sourceItem.tree = tree
+
 	return nil
 }
 
+/*
+sourceMap creates a new SourceItem of SOURCE_ITEM_TYPE_CONTENT_MAP type
+for passed nested map (see Client.AddLocale()), which is loaded as is.
+Content of SourceItem is a canonical representation of that map,
+it's used to calculate MD5 hash sum only.
+*/
+func (c *Client) sourceMap(dest *[]SourceItem, m sourceMapArg) *ekaerr.Error {
+	const s = "Failed to analyse provided map as a locale source. "
+
+	file := sourceCaller()
+
+	switch localeName := normalizeLocaleName(m.localeName); {
+
+	case !isValidLocaleName(localeName):
+		return ekaerr.IllegalArgument.
+			New(s + "Locale name has an incorrect format. Should be: xx_YY, xx_Scrp_YY or xx.").
+			AddFields(
+				"privet_source_path", file,
+				"privet_locale_name", m.localeName).
+			Throw()
+
+	case len(m.content) == 0:
+		return ekaerr.IllegalFormat.
+			New(s + "Map is empty.").
+			AddFields("privet_source_path", file).
+			Throw()
+
+	default:
+		m.localeName = localeName
+	}
+
+	var content bytes.Buffer
+
+	content.WriteString(m.localeName)
+	content.WriteByte(0)
+
+	sourceMapContent(&content, "", m.content)
+
+	md5sum := md5.Sum(content.Bytes())
+
+	c.sourceApprove(dest, SOURCE_ITEM_TYPE_CONTENT_MAP, file, content.Bytes(), md5sum[:], time.Time{})
+
+	sourceItem := &(*dest)[len(*dest)-1]
+	sourceItem.LocaleName = m.localeName
+	sourceItem.sourcedName = m.localeName
+	sourceItem.tree = m.content
+
+	return nil
+}
+
+/*
+sourceMapContent writes a canonical representation of passed nested map to b:
+the full keys (prefixed by prefix) and values of phrases, sorted by keys.
+*/
+func sourceMapContent(b *bytes.Buffer, prefix string, m map[string]interface{}) {
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if subMap, ok := m[key].(map[string]interface{}); ok {
+			sourceMapContent(b, prefix + key + string(DEFAULT_DELIMITER), subMap)
+			continue
+		}
+		b.WriteString(prefix + key)
+		b.WriteByte(0)
+		b.WriteString(fmt.Sprint(m[key]))
+		b.WriteByte(0)
+	}
+}
+
+/*
+sourceFile creates a new SourceItem for passed f, reading its content.
+
+If f is *os.File, its name is used as a path of SourceItem,
+otherwise the name from f's stat is used.
+If that path has a supported extension, SourceItem is the file of that format,
+and a locale name may be found in the path (see findLocaleInFilepath()).
+Otherwise, it's a content of unknown format, that must contain metadata,
+and if there is no name at all, a caller is used as a path like sourceBytes() does.
+
+f is not closed. Directories are not allowed.
+*/
+func (c *Client) sourceFile(dest *[]SourceItem, f fs.File) *ekaerr.Error {
+	const s = "Failed to analyse provided file as a locale source. "
+
+	fi, legacyErr := f.Stat()
+	if legacyErr != nil {
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to get file's stat.").
+			Throw()
+	}
+
+	path := fi.Name()
+	if osFile, ok := f.(*os.File); ok {
+		path = osFile.Name()
+		if absPath, legacyErr := filepath.Abs(path); legacyErr == nil {
+			path = absPath
+		}
+	}
+
+	if fi.IsDir() {
+		return ekaerr.IllegalArgument.
+			New(s + "File is a directory. Use its path instead.").
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	typ, isSupported := sourceItemTypeByExt(path)
+	if !isSupported {
+		typ = SOURCE_ITEM_TYPE_CONTENT_UNKNOWN
+	}
+	if path == "" {
+		path = sourceCaller()
+	}
+
+	if err := c.sourceCheckSize(fi.Size()); err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	content, md5sum, legacyErr := c.sourceRead(f)
+	if legacyErr != nil {
+		return ekaerr.DataUnavailable.
+			Wrap(legacyErr, s + "Failed to read file and calculate its MD5 hash sum.").
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	if err := c.sourceCheckSize(int64(len(content))); err.IsNotNil() {
+		return err.
+			AddMessage(s).
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	if len(content) == 0 {
+		return ekaerr.IllegalFormat.
+			New(s + "File is empty.").
+			AddFields("privet_source_path", path).
+			Throw()
+	}
+
+	c.sourceApprove(dest, typ, path, content, md5sum, fi.ModTime())
+	return nil
+}
+
+/*
+sourceRead reads all data from r, calculating its MD5 hash sum
+at the same time, chunk by chunk.
+Returns a copy of read data, so it's safe to keep it.
+*/
+func (c *Client) sourceRead(r io.Reader) (content, md5sum []byte, err error) {
+
+	h := md5.New()
+
+	// We don't have Client's fields initialization.
+	// So, initialize buf here if it's not yet so.
+	if c.buf.Cap() == 0 {
+		c.buf.Grow(64 * 1024)
+	}
+	c.buf.Reset()
+
+	// Read one byte more than allowed,
+	// so the caller may detect that the limit is exceeded.
+	if maxSize := atomic.LoadInt64(&c.maxSourceFileSize); maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+
+	if _, err = io.Copy(io.MultiWriter(h, &c.buf), r); err != nil {
+		return nil, nil, err
+	}
+
+	return append([]byte(nil), c.buf.Bytes()...), h.Sum(nil), nil
+}
+
+/*
+sourceCheckSize returns an error if passed size of source's content
+is greater than Config.MaxSourceFileSize (if it's set).
+*/
+func (c *Client) sourceCheckSize(size int64) *ekaerr.Error {
+
+	maxSize := atomic.LoadInt64(&c.maxSourceFileSize)
+	if maxSize <= 0 || size <= maxSize {
+		return nil
+	}
+
+	return ekaerr.IllegalFormat.
+		New("Source is too big. Config.MaxSourceFileSize is exceeded.").
+		AddFields(
+			"privet_source_size",     size,
+			"privet_source_max_size", maxSize).
+		Throw()
+}
+
+/*
+sourceItemTypeByExt returns a type of file SourceItem depends on the extension
+of passed path. The 2nd returned value is false if extension is not supported.
+*/
+func sourceItemTypeByExt(path string) (SourceItemType, bool) {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != "" {
+		ext = ext[1:]
+	}
+
+	switch ext {
+	case "yml", "yaml":
+		return SOURCE_ITEM_TYPE_FILE_YAML, true
+	case "json":
+		return SOURCE_ITEM_TYPE_FILE_JSON, true
+	case "toml":
+		return SOURCE_ITEM_TYPE_FILE_TOML, true
+	case "po":
+		return SOURCE_ITEM_TYPE_FILE_PO, true
+	default:
+		return 0, false
+	}
+}
+
+/*
+sourceCaller returns a "<filepath>:<line>" of the first caller
+outside of this package, which is used as a path of SourceItem,
+that represents a RAW data (content) and has no real path.
+*/
+func sourceCaller() string {
+
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, _PACKAGE_PATH + ".") && frame.File != "" {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return "Source undefined. Failed to extract caller information."
+		}
+	}
+}
+
 /*
 sourceApprove is just _SourceItem constructor with passed typ, path, content arguments
 and appender to the dest.
+modTime is a last modification time of source's file, zero for a RAW data.
 */
-func (_ *Client) sourceApprove(dest *[]SourceItem, typ SourceItemType, path string, content, md5sum []byte) {
+func (_ *Client) sourceApprove(
+
+	dest    *[]SourceItem,
+	typ     SourceItemType,
+	path    string,
+	content []byte,
+	md5sum  []byte,
+	modTime time.Time,
+
+) {
 	*dest = append(*dest, SourceItem{
 		Type:    typ,
 		Path:    path,
 		content: content,
 		md5:     hex.EncodeToString(md5sum),
+		modTime: modTime,
 	})
 }
+
+/*
+verifySources is a VerifySources() implementation.
+Files are read w/o using Client's buffer, so it's safe
+to call it concurrently with Source() calls.
+*/
+func (c *Client) verifySources() ([]string, *ekaerr.Error) {
+	const s = "Failed to verify locale source files. "
+
+	switch {
+
+	case !c.isValid():
+		return nil, ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+
+	case c.getStorage() == nil:
+		return nil, ekaerr.IllegalState.
+			New(s + "Locales are not loaded yet.").
+			Throw()
+	}
+
+	var changed []string
+
+	for _, sourceItem := range c.getSources() {
+
+		if !sourceItem.isFile() || sourceItem.fsys != nil {
+			continue
+		}
+
+		md5sum, legacyErr := sourceFileMD5(sourceItem.Path)
+		switch {
+
+		case os.IsNotExist(legacyErr):
+			changed = append(changed, sourceItem.Path)
+
+		case legacyErr != nil:
+			return nil, ekaerr.DataUnavailable.
+				Wrap(legacyErr, s + "Failed to read file and calculate its MD5 hash sum.").
+				AddFields("privet_source_path", sourceItem.Path).
+				Throw()
+
+		case md5sum != sourceItem.md5:
+			changed = append(changed, sourceItem.Path)
+		}
+	}
+
+	return changed, nil
+}
+
+/*
+sourceFileMD5 returns the hex encoded MD5 hash sum of the file by its path,
+the same way SourceItem.md5 is calculated.
+*/
+func sourceFileMD5(path string) (string, error) {
+
+	f, legacyErr := os.Open(path)
+	if legacyErr != nil {
+		return "", legacyErr
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, legacyErr = io.Copy(h, f); legacyErr != nil {
+		return "", legacyErr
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,287 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientConcurrentLoad(t *testing.T) {
+
+	var c Client
+	if err := c.AddLocale("en_US", map[string]interface{}{"key0": "value"}); err.IsNotNil() {
+		t.Fatal("failed to add locale")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 1; i < 100; i++ {
+			key := "key" + strconv.Itoa(i)
+			if err := c.AddLocale("en_US", map[string]interface{}{key: "value"}); err.IsNotNil() {
+				t.Error("failed to add locale")
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.Tr("en_US", "key0", nil)
+			c.FormatStats()
+			c.Snapshot()
+			c.LC("en_US").LastModified()
+			// It's an error if Load() is in progress, that's OK.
+			_, _ = c.VerifySources()
+		}
+	}()
+
+	wg.Wait()
+
+	if stats := c.FormatStats(); stats[SOURCE_ITEM_TYPE_CONTENT_MAP] != 100 {
+		t.Errorf("FormatStats() = %v, expected 100 maps", stats)
+	}
+}
+
+func TestClientReadersDuringReload(t *testing.T) {
+
+	dir := t.TempDir()
+
+	enPath := writeTestFile(t, dir, "en_US.yaml", "title: Title\nmenu:\n  open: Open\n", time.Time{})
+	ruPath := writeTestFile(t, dir, "ru_RU.yaml", "__metadata__:\n  inherits: en_US\ntitle: Заголовок\n", time.Time{})
+
+	var c Client
+	c.SetFallbackToDefault(true)
+
+	if err := c.Source([]string{enPath, ruPath}); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+	c.LC("en_US").MarkAsDefault()
+
+	var (
+		wg    sync.WaitGroup
+		reads uint32
+	)
+
+	wg.Add(1)
+
+	// Readers work until the locales are reloaded many times meanwhile,
+	// so they do hit the moments the reloading is in progress.
+
+	go func() {
+		defer wg.Done()
+		for ; atomic.LoadUint32(&reads) < 2000; atomic.AddUint32(&reads, 1) {
+
+			tests := []struct {
+				name     string
+				actual   string
+				expected string
+			}{
+				{"LC", c.LC("ru_RU").Tr("title", nil), "Заголовок"},
+				{"Tr", c.Tr("en_US", "title", nil), "Title"},
+				{"inherited", c.Tr("ru_RU", "menu/open", nil), "Open"},
+				{"default", c.Tr("de_DE", "title", nil), "Title"},
+				{"TrChain", c.TrChain("title", nil, "de_DE", "ru_RU"), "Заголовок"},
+			}
+
+			for _, test := range tests {
+				if test.actual != test.expected {
+					t.Errorf("%s: %q, expected %q", test.name, test.actual, test.expected)
+					atomic.StoreUint32(&reads, 2000)
+					return
+				}
+			}
+			if c.Default() == nil {
+				t.Error("Default() is nil")
+				atomic.StoreUint32(&reads, 2000)
+				return
+			}
+		}
+	}()
+
+	for atomic.LoadUint32(&reads) < 2000 {
+		if err := c.Reload(); err.IsNotNil() {
+			t.Error("failed to reload")
+			break
+		}
+		if err := c.ReloadFile(ruPath); err.IsNotNil() {
+			t.Error("failed to reload file")
+			break
+		}
+	}
+
+	atomic.StoreUint32(&reads, 2000)
+	wg.Wait()
+}
+
+func TestClientUnloadKeepsPendingSources(t *testing.T) {
+
+	var c Client
+	if err := c.AddLocale("en_US", map[string]interface{}{"old": "Old"}); err.IsNotNil() {
+		t.Fatal("failed to add locale")
+	}
+
+	if err := c.Source(RawSource{
+		Format: "yaml",
+		Data:   []byte("new: Новый"),
+		Name:   "ru_RU",
+	}); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+
+	if err := c.Unload(); err.IsNotNil() {
+		t.Fatal("failed to unload locales while sources are pending")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load pending sources")
+	}
+
+	if c.LC("en_US") != nil {
+		t.Error("unloaded locale en_US is still loaded")
+	}
+	if translated := c.Tr("ru_RU", "new", nil); translated != "Новый" {
+		t.Errorf("Tr() = %q, expected %q", translated, "Новый")
+	}
+
+	var empty Client
+	if err := empty.Unload(); err.IsNil() {
+		t.Error("Unload() of not loaded Client must fail")
+	}
+}
+
+func TestClientMergeRequiredArgs(t *testing.T) {
+
+	var (
+		withArgs = map[string]interface{}{
+			"__metadata__": map[string]interface{}{
+				"required_args": map[string]interface{}{"greeting": []interface{}{"name"}},
+			},
+			"greeting": "Hi, {{name}}",
+		}
+		withoutArgs = map[string]interface{}{
+			"greeting": "Hi",
+		}
+	)
+
+	tests := []struct {
+		name      string
+		content   map[string]interface{}
+		other     map[string]interface{}
+		overwrite bool
+		expected  string
+	}{
+		{"kept phrase keeps args", withArgs, withoutArgs, false, "Hi, {{name}}"},
+		{"overwritten phrase drops args", withArgs, withoutArgs, true, "Hi"},
+		{"kept phrase takes no args", withoutArgs, withArgs, false, "Hi"},
+		{"overwriting phrase brings args", withoutArgs, withArgs, true, "Hi, {{name}}"},
+	}
+
+	for _, test := range tests {
+		var c, other Client
+		if err := c.AddLocale("en_US", test.content); err.IsNotNil() {
+			t.Fatalf("%s: failed to add locale", test.name)
+		}
+		if err := other.AddLocale("en_US", test.other); err.IsNotNil() {
+			t.Fatalf("%s: failed to add other's locale", test.name)
+		}
+
+		if err := c.Merge(&other, test.overwrite); err.IsNotNil() {
+			t.Errorf("%s: failed to merge, maybe stale required args are checked", test.name)
+			continue
+		}
+
+		loc := c.LC("en_US")
+		if translated, _, _ := loc.lookup("greeting"); translated != test.expected {
+			t.Errorf("%s: phrase = %q, expected %q", test.name, translated, test.expected)
+		}
+		if _, isRequired := loc.requiredArgs["greeting"]; isRequired != (test.expected != "Hi") {
+			t.Errorf("%s: required args = %v", test.name, loc.requiredArgs)
+		}
+	}
+}
+
+func TestClientKeyTransformDuplicates(t *testing.T) {
+
+	tests := []struct {
+		name            string
+		continueOnError bool
+		overwrite       bool
+	}{
+		{"error", false, false},
+		{"error even if overwriting is allowed", false, true},
+		{"conflict", true, false},
+	}
+
+	for _, test := range tests {
+		var c Client
+		c.SetKeyTransform(strings.ToLower)
+		c.SetContinueOnSourceError(test.continueOnError)
+		if test.overwrite {
+			c.config.OverwriteExistingKey = 1
+		}
+
+		err := c.AddLocale("en_US", map[string]interface{}{"Title": "A", "title": "B", "other": "C"})
+
+		if err.IsNil() != test.continueOnError {
+			t.Errorf("%s: is failed: %t", test.name, err.IsNotNil())
+			continue
+		}
+		if !test.continueOnError {
+			continue
+		}
+		if conflicts := c.LastLoadReport().Conflicts; len(conflicts) != 1 || conflicts[0].Key != "title" {
+			t.Errorf("%s: conflicts = %v, expected one of \"title\" key", test.name, conflicts)
+		}
+	}
+}
+
+func TestClientTrChain(t *testing.T) {
+
+	var c Client
+	c.SetStrictKeyPath(true)
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"menu":  map[string]interface{}{"open": "Open"},
+		"title": "Title",
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+	if err := c.AddLocale("ru_RU", map[string]interface{}{"menu": "Меню"}); err.IsNotNil() {
+		t.Fatal("failed to add ru_RU")
+	}
+
+	tests := []struct {
+		name        string
+		key         string
+		localeNames []string
+		expected    string
+	}{
+		{"first locale", "title", []string{"en_US", "ru_RU"}, "Title"},
+		{"next locale after not found", "title", []string{"ru_RU", "en_US"}, "Title"},
+		{"next locale after node", "menu", []string{"en_US", "ru_RU"}, "Меню"},
+		{"not loaded locale skipped", "menu", []string{"de_DE", "ru_RU"}, "Меню"},
+		{"first class is kept", "menu", []string{"en_US", "de_DE"}, sptr(_SPTR_TRANSLATION_KEY_IS_NODE, "menu")},
+		{"not found anywhere", "other", []string{"ru_RU", "en_US"}, sptr(_SPTR_TRANSLATION_NOT_FOUND, "other")},
+		{"no loaded locale", "title", []string{"de_DE"}, sptr(_SPTR_LOCALE_IS_NIL, "title")},
+	}
+
+	for _, test := range tests {
+		if translated := c.TrChain(test.key, nil, test.localeNames...); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+	}
+}
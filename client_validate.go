@@ -0,0 +1,152 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"sort"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+)
+
+type (
+	/*
+	VerbsMismatch describes one language phrase, which interpolation verbs
+	mismatch the verbs of the same phrase of the base Locale.
+	Verbs are formatted as verbs (e.g: "{{name}}").
+
+	VerbsMismatch is returned by Client.ValidateVerbs().
+	*/
+	VerbsMismatch struct {
+		LocaleName string
+		Key        string   // full translation key
+		Missing    []string // verbs of the base phrase that are dropped
+		Extra      []string // verbs that are not presented in the base phrase
+	}
+)
+
+/*
+ValidateVerbs compares the interpolation verbs (see Locale.Verbs())
+of each language phrase of the base Locale with the verbs of the same phrase
+of all other loaded Locales, and reports the mismatches: when a translator
+dropped a verb (e.g: "{{name}}") or added an unknown one.
+It's designed to be called right after Load() or from CI step,
+to catch interpolation bugs before they are in production.
+
+Phrases are looked up the same way Tr() does, so inherited phrases are compared too.
+Phrases that are missing in other Locales are not reported.
+
+Returns all found mismatches, sorted by the Locale's name and the key.
+Returns an error if Client is not loaded yet, there is no base Locale,
+or any mismatch is found. In the latter case the mismatches are returned too,
+and the error has "privet_verbs_mismatches_count" field.
+*/
+func (c *Client) ValidateVerbs(base string) ([]VerbsMismatch, *ekaerr.Error) {
+	const s = "Failed to validate interpolation verbs. "
+
+	if !c.isValid() {
+		return nil, ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+	}
+
+	// Locales are taken from one snapshot, even if they are reloaded meanwhile,
+	// so the base Locale is taken from the same snapshot as others are.
+
+	storage := c.getStorage()
+	if storage == nil {
+		return nil, ekaerr.IllegalState.
+			New(s + "There is no loaded locales.").
+			Throw()
+	}
+
+	baseLoc := c.getLocale(base)
+	if baseLoc != nil {
+		baseLoc = storage[baseLoc.name]
+	}
+	if baseLoc == nil {
+		return nil, ekaerr.NotFound.
+			New(s + "Base locale is not found.").
+			AddFields("privet_locale_name", base).
+			Throw()
+	}
+
+	basePhrases := baseLoc.flatten()
+
+	keys := make([]string, 0, len(basePhrases))
+	for key := range basePhrases {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	localeNames := make([]string, 0, len(storage))
+	for localeName := range storage {
+		if localeName != baseLoc.name {
+			localeNames = append(localeNames, localeName)
+		}
+	}
+	sort.Strings(localeNames)
+
+	var mismatches []VerbsMismatch
+	for _, localeName := range localeNames {
+		loc := storage[localeName]
+
+		for _, key := range keys {
+			translatedPhrase, _, class := loc.lookupInherited(key)
+			if class != "" {
+				continue
+			}
+
+			baseVerbs := phraseVerbs(basePhrases[key])
+			verbs := phraseVerbs(translatedPhrase)
+
+			missing := verbsDiff(baseVerbs, verbs)
+			extra := verbsDiff(verbs, baseVerbs)
+
+			if len(missing) == 0 && len(extra) == 0 {
+				continue
+			}
+
+			mismatches = append(mismatches, VerbsMismatch{
+				LocaleName: localeName,
+				Key:        key,
+				Missing:    missing,
+				Extra:      extra,
+			})
+		}
+	}
+
+	if len(mismatches) != 0 {
+		return mismatches, ekaerr.IllegalFormat.
+			New(s + "Some phrases have interpolation verbs, that mismatch the base locale.").
+			AddFields(
+				"privet_base_locale",            baseLoc.name,
+				"privet_verbs_mismatches_count", len(mismatches)).
+			Throw()
+	}
+
+	return nil, nil
+}
+
+/*
+verbsDiff returns the verbs from a that are not presented in b,
+formatted as verbs (e.g: "{{name}}").
+*/
+func verbsDiff(a, b []string) []string {
+
+	var diff []string
+
+outer:
+	for _, verbA := range a {
+		for _, verbB := range b {
+			if verbA == verbB {
+				continue outer
+			}
+		}
+		diff = append(diff, "{{" + verbA + "}}")
+	}
+
+	return diff
+}
@@ -0,0 +1,80 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientValidateVerbs(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"Menu": map[string]interface{}{
+			"Greeting": "Hi, {{name}}",
+			"Total":    "{{count}} of {{total}}",
+			"Title":    "Menu",
+		},
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+	if err := c.AddLocale("ru_RU", map[string]interface{}{
+		"Menu": map[string]interface{}{
+			"Greeting": "Привет, {{user}}",
+			"Total":    "{{count}} из {{total}}",
+		},
+	}); err.IsNotNil() {
+		t.Fatal("failed to add ru_RU")
+	}
+	if err := c.AddLocale("de_DE", map[string]interface{}{
+		"Menu": map[string]interface{}{
+			"Total": "{{count}}",
+			"Title": "Menü {{name}}",
+		},
+	}); err.IsNotNil() {
+		t.Fatal("failed to add de_DE")
+	}
+
+	tests := []struct {
+		name     string
+		base     string
+		expected []VerbsMismatch
+	}{
+		{
+			name: "en_US base",
+			base: "en_US",
+			expected: []VerbsMismatch{
+				{LocaleName: "de_DE", Key: "Menu/Title", Extra: []string{"{{name}}"}},
+				{LocaleName: "de_DE", Key: "Menu/Total", Missing: []string{"{{total}}"}},
+				{LocaleName: "ru_RU", Key: "Menu/Greeting", Missing: []string{"{{name}}"}, Extra: []string{"{{user}}"}},
+			},
+		},
+		{
+			name: "ru_RU base",
+			base: "ru_RU",
+			expected: []VerbsMismatch{
+				{LocaleName: "de_DE", Key: "Menu/Total", Missing: []string{"{{total}}"}},
+				{LocaleName: "en_US", Key: "Menu/Greeting", Missing: []string{"{{user}}"}, Extra: []string{"{{name}}"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		mismatches, err := c.ValidateVerbs(test.base)
+		if err.IsNil() {
+			t.Errorf("%s: expected an error", test.name)
+		}
+		if !reflect.DeepEqual(mismatches, test.expected) {
+			t.Errorf("%s: %+v, expected %+v", test.name, mismatches, test.expected)
+		}
+	}
+
+	if mismatches, err := c.ValidateVerbs("fr_FR"); err.IsNil() || mismatches != nil {
+		t.Errorf("unknown base: expected an error and no mismatches")
+	}
+}
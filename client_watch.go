@@ -0,0 +1,129 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+)
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	/*
+	_WATCH_INTERVAL is how often Client.Watch() checks
+	whether loaded locale source files are changed.
+	*/
+	_WATCH_INTERVAL = time.Second
+)
+
+/*
+Watch watches loaded locale source files for changes until ctx is done,
+and reloads each changed file, the same way as ReloadFile() does,
+so translators may update them w/o restarting the server.
+Each reloading atomically replaces the loaded locales. Readers are not blocked:
+LC(), Tr() and others use the previously loaded locales meanwhile (see LC()).
+
+Files are polled each second by their modification time.
+Only the files that are loaded by the time of the check are watched,
+new files in the sourced directories are not. Files of fs.FS are not watched too.
+
+If the changed file can not be reloaded (e.g: it's malformed or removed),
+the previously loaded locales are kept, and the error is passed to OnWatchError
+callback (see SetOnWatchError()). The file is tried again, when it's changed again.
+Checks are skipped while another Source() or Load() is in progress.
+
+It blocks until ctx is done, so run it in a separate goroutine:
+
+        go privet.Watch(ctx)
+
+Returns nil when ctx is done, or an error immediately if locales are not loaded yet.
+*/
+func (c *Client) Watch(ctx context.Context) *ekaerr.Error {
+	const s = "Failed to watch locale source files. "
+
+	switch {
+
+	case !c.isValid():
+		return ekaerr.IllegalState.
+			New(s + "Client is not valid.").
+			Throw()
+
+	case c.getStorage() == nil:
+		return ekaerr.IllegalState.
+			New(s + "Locales are not loaded yet.").
+			Throw()
+	}
+
+	ticker := time.NewTicker(_WATCH_INTERVAL)
+	defer ticker.Stop()
+
+	// Modification times of files that are tried to be reloaded,
+	// so the failed ones are not reloaded again until they are changed again.
+	failedModTimes := make(map[string]time.Time)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		for _, path := range c.watchChangedFiles(failedModTimes) {
+
+			// Another Source(), Load(), etc might be called since the check.
+			// The file isn't tried to be reloaded, so it's checked again the next time.
+
+			if !c.changeState(_LLS_READY, _LLS_LOAD_PENDING) {
+				delete(failedModTimes, path)
+				continue
+			}
+
+			if err := c.reloadFileLocked(path); err.IsNotNil() {
+				c.callOnWatchError(path, err)
+			}
+		}
+	}
+}
+
+/*
+watchChangedFiles returns the paths of loaded locale source files,
+which modification time differs from the one they had when were loaded,
+and from the one of failedModTimes, that is updated by the current ones.
+The paths of files that can not be stat'ed are also returned once.
+*/
+func (c *Client) watchChangedFiles(failedModTimes map[string]time.Time) []string {
+
+	if c.getState() != _LLS_READY {
+		return nil
+	}
+
+	var changed []string
+
+	for _, sourceItem := range c.getSources() {
+
+		if !sourceItem.isFile() || sourceItem.fsys != nil {
+			continue
+		}
+
+		var modTime time.Time
+		if fi, legacyErr := os.Stat(sourceItem.Path); legacyErr == nil {
+			modTime = fi.ModTime()
+		}
+
+		failedModTime, isFailed := failedModTimes[sourceItem.Path]
+		if modTime.Equal(sourceItem.modTime) || isFailed && modTime.Equal(failedModTime) {
+			continue
+		}
+
+		failedModTimes[sourceItem.Path] = modTime
+		changed = append(changed, sourceItem.Path)
+	}
+
+	return changed
+}
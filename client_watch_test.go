@@ -0,0 +1,84 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+)
+
+func TestClientWatch(t *testing.T) {
+
+	dir := t.TempDir()
+	loadedAt := time.Now().Add(-time.Hour)
+
+	path := writeTestFile(t, dir, "en_US.yaml", "title: Title\n", loadedAt)
+
+	var c Client
+	if err := c.Source(path); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+
+	var watchErrors uint32
+	c.SetOnWatchError(func(_ string, _ *ekaerr.Error) {
+		atomic.AddUint32(&watchErrors, 1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan *ekaerr.Error)
+
+	go func() {
+		stopped <- c.Watch(ctx)
+	}()
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    string
+		watchErrors uint32
+	}{
+		{"modified", "title: New Title\n", "New Title", 0},
+		{"malformed", "title: [\n", "New Title", 1},
+		{"fixed", "title: Fixed Title\n", "Fixed Title", 1},
+	}
+
+	for i, test := range tests {
+		// Each next modification time differs, even if the file system
+		// has a coarse resolution of modification times.
+		modTime := loadedAt.Add(time.Duration(i + 1) * time.Minute)
+		writeTestFile(t, dir, filepath.Base(path), test.content, modTime)
+
+		deadline := time.Now().Add(5 * _WATCH_INTERVAL)
+		for time.Now().Before(deadline) {
+			if c.Tr("en_US", "title", nil) == test.expected &&
+				atomic.LoadUint32(&watchErrors) == test.watchErrors {
+				break
+			}
+			time.Sleep(_WATCH_INTERVAL / 10)
+		}
+
+		if translated := c.Tr("en_US", "title", nil); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+		if n := atomic.LoadUint32(&watchErrors); n != test.watchErrors {
+			t.Errorf("%s: %d watch errors, expected %d", test.name, n, test.watchErrors)
+		}
+	}
+
+	cancel()
+
+	if err := <-stopped; err.IsNotNil() {
+		t.Error("Watch() is stopped with error")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strings"
+
+	"github.com/qioalice/ekago/v2/ekastr"
+)
+
+type (
+	/*
+	phraseToken is a part of the language phrase, pre-split by compilePhrase():
+	either a just text or an interpolation verb.
+	The interpolator consumes the tokens instead of scanning the phrase again
+	for each Tr() call, if Config.CompilePhrases is enabled.
+	*/
+	phraseToken struct {
+		text   string // just text, or the whole verb with braces (e.g: "{{ name }}")
+		verb   string // verb w/o braces and surrounding spaces, only if isVerb
+		isVerb bool
+	}
+)
+
+/*
+compilePhrase splits passed phrase into the just text and interpolation verbs tokens
+using the same verbs scanning interpolate() does, so the escaped "{{"
+(see interpolateEscaped()) is a just text token already.
+Adjacent just text parts are joined into one token.
+*/
+func compilePhrase(phrase string) []phraseToken {
+
+	var tokens []phraseToken
+	interpolateEscaped(ekastr.S2B(phrase),
+		func(p []byte) {
+			tokens = append(tokens, phraseToken{
+				text:   string(p),
+				verb:   strings.TrimSpace(string(p[2:len(p)-2])),
+				isVerb: true,
+			})
+		},
+		func(p []byte) {
+			if n := len(tokens); n != 0 && !tokens[n-1].isVerb {
+				tokens[n-1].text += string(p)
+			} else {
+				tokens = append(tokens, phraseToken{text: string(p)})
+			}
+		})
+
+	return tokens
+}
+
+/*
+compileStorage stores the tokens (see compilePhrase()) of each language phrase
+of each Locale from passed storage in the phrase's localeNode along with it,
+so Locale.lookup() returns them w/o any extra lookup.
+The phrases w/o interpolation verbs and escapes are skipped,
+because they are never interpolated.
+
+Requirements:
+ - Locales of storage are not in use yet (e.g: they are clone()'s copies).
+*/
+func compileStorage(storage map[string]*Locale) {
+
+	for _, loc := range storage {
+		loc.root.applyRecursively(func(node *localeNode) {
+			node.compiled = nil
+			for key, translatedPhrase := range node.content {
+				if !strings.Contains(translatedPhrase, "{{") {
+					continue
+				}
+				if node.compiled == nil {
+					node.compiled = make(map[string][]phraseToken)
+				}
+				node.compiled[key] = compilePhrase(translatedPhrase)
+			}
+		})
+	}
+}
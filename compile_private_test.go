@@ -0,0 +1,87 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"testing"
+)
+
+var compileTestContent = map[string]interface{}{
+	"flat":    "Hi, {{name}}! You have {{count}} new messages.",
+	"escaped": "Type {{{{name}} to insert {{name}}",
+	"plain":   "Just text",
+	"Menu": map[string]interface{}{
+		"Greeting": "Welcome back, {{ name }}",
+		"items":    map[string]interface{}{"one": "{{count}} item", "other": "{{count}} items"},
+	},
+}
+
+func newCompileTestClient(tb testing.TB, compile bool) *Client {
+	tb.Helper()
+
+	var c Client
+	c.SetCompilePhrases(compile)
+
+	if err := c.AddLocale("en_US", compileTestContent); err.IsNotNil() {
+		tb.Fatal("failed to add en_US")
+	}
+
+	return &c
+}
+
+func TestLocaleTrCompiled(t *testing.T) {
+
+	compiled := newCompileTestClient(t, true).LC("en_US")
+	scanned := newCompileTestClient(t, false).LC("en_US")
+
+	if tokens := compiled.root.compiled["flat"]; len(tokens) != 5 {
+		t.Errorf("flat: %d tokens, expected 5", len(tokens))
+	}
+	if tokens := compiled.root.compiled["plain"]; tokens != nil {
+		t.Errorf("plain: %d tokens, expected not compiled", len(tokens))
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		args Args
+	}{
+		{"flat", "flat", Args{"name": "Bob", "count": 3}},
+		{"escaped", "escaped", Args{"name": "Bob"}},
+		{"plain", "plain", nil},
+		{"nested", "Menu/Greeting", Args{"name": "Bob"}},
+		{"plural", "Menu/items", Args{"count": 1}},
+		{"missing arg", "flat", Args{"name": "Bob"}},
+	}
+
+	for _, test := range tests {
+		expected := scanned.Tr(test.key, test.args)
+		if translated := compiled.Tr(test.key, test.args); translated != expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, expected)
+		}
+	}
+}
+
+func BenchmarkLocaleTr(b *testing.B) {
+
+	args := Args{"name": "Bob"}
+
+	for _, compile := range []bool{false, true} {
+		name := "Scanned"
+		if compile {
+			name = "Compiled"
+		}
+
+		loc := newCompileTestClient(b, compile).LC("en_US")
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = loc.Tr("Menu/Greeting", args)
+			}
+		})
+	}
+}
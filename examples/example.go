@@ -6,23 +6,21 @@
 package main
 
 import (
+	"embed"
 	"fmt"
-	"path/filepath"
-	"runtime"
 
 	"github.com/qioalice/privet/v2"
 )
 
-func main() {
+// Locale files are embedded into the binary,
+// so it doesn't matter where it's started from.
 
-	// Scan starting from directory,
-	// the current example.go file is placed in.
+//go:embed locales
+var locales embed.FS
 
-	_, source, _, _ := runtime.Caller(0)
-	source = filepath.Dir(source)
-	source = filepath.Join(source, ".") // in JS: source = source | "."
+func main() {
 
-	privet.Source(source).LogAsFatal()
+	privet.Source(locales).LogAsFatal()
 	privet.Load().LogAsFatal()
 
 	privet.LC("en_US").MarkAsDefault()
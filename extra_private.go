@@ -6,20 +6,305 @@
 package privet
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
 	"github.com/qioalice/ekago/v2/ekastr"
+	"golang.org/x/text/language"
 )
 
 /*
 isValidLocaleName reports whether passed s is a valid locale name
-that is in the following format "xx_YY", where:
- - xx is a lower case chars of language name ("en", "ru", "jp"),
- - YY is a upper case chars of country name ("US", "GB", "RU").
+that is a canonical BCP 47 like tag (see parseLocaleName()):
+"xx_YY", "xx_Scrp_YY", "xx_Scrp" or just "xx".
 */
 func isValidLocaleName(s string) bool {
-	return len(s) == 5 &&
-		ekastr.CharIsLowerCaseLetter(s[0]) &&
-		ekastr.CharIsLowerCaseLetter(s[1]) &&
-		ekastr.CharIsUpperCaseLetter(s[3]) &&
-		ekastr.CharIsUpperCaseLetter(s[4]) &&
-		s[2] == '_'
+	language, script, region := parseLocaleName(s)
+	return language != "" && joinLocaleName(language, script, region) == s
+}
+
+/*
+isValidFullLocaleName reports whether passed s is a valid locale name
+(see isValidLocaleName()) that has a script or region subtag besides language,
+like "en_US", "zh_Hans_CN" or "sr_Latn".
+*/
+func isValidFullLocaleName(s string) bool {
+	return isValidLocaleName(s) && strings.IndexByte(s, '_') != -1
+}
+
+/*
+isKnownFullLocaleName reports whether passed s is a full locale name
+(see isValidFullLocaleName()) which subtags are registered ones,
+so "en_US" and "zh_Hans_CN" are, but "my_Docs" is not.
+The language must be 2 letters long, unless isFileName is true:
+3 letters codes are alike the words (e.g: "dev_QA"), so they are trusted
+in the file names only.
+*/
+func isKnownFullLocaleName(s string, isFileName bool) bool {
+
+	if !isValidFullLocaleName(s) {
+		return false
+	}
+
+	lang, script, region := parseLocaleName(s)
+
+	switch {
+	case len(lang) != 2 && !isFileName:
+		return false
+	case !isKnownLanguageName(lang):
+		return false
+	case script != "":
+		if _, legacyErr := language.ParseScript(script); legacyErr != nil {
+			return false
+		}
+	}
+
+	if region != "" {
+		if _, legacyErr := language.ParseRegion(region); legacyErr != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+isKnownLanguageName reports whether passed s is a language-only locale name
+that is in the following format "xx" or "xxx", where xx (xxx) is a lower case chars
+of language code registered by ISO 639 ("en", "ru", "fil").
+Unlike full locale names, language codes are alike the words ("ui", "db"),
+so they are checked against the registered ones.
+*/
+func isKnownLanguageName(s string) bool {
+	if len(s) < 2 || len(s) > 3 || strings.ToLower(s) != s {
+		return false
+	}
+	_, legacyErr := language.ParseBase(s)
+	return legacyErr == nil && s != "und"
+}
+
+/*
+parseLocaleName parses s as a BCP 47 like locale tag and returns its canonicalized
+subtags. Subtags might be separated by either "_" or "-" and are case insensitive:
+ - language is 2 or 3 letters, lower cased ("en", "zh", "fil"),
+ - script is optional 4 letters, title cased ("Hans", "Latn"),
+ - region is optional 2 letters, upper cased ("US", "CN") or 3 digits ("419").
+Leading and trailing spaces are ignored.
+Returns empty strings if s is not a locale tag.
+*/
+func parseLocaleName(s string) (language, script, region string) {
+
+	subtags := strings.FieldsFunc(strings.TrimSpace(s), func(r rune) bool {
+		return r == '_' || r == '-'
+	})
+
+	if len(subtags) == 0 || len(subtags) > 3 || strings.Count(s, "_") + strings.Count(s, "-") != len(subtags) - 1 {
+		return "", "", ""
+	}
+
+	isLetters := func(s string) bool {
+		for i, n := 0, len(s); i < n; i++ {
+			if !ekastr.CharIsLowerCaseLetter(s[i]) && !ekastr.CharIsUpperCaseLetter(s[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	isDigits := func(s string) bool {
+		for i, n := 0, len(s); i < n; i++ {
+			if s[i] < '0' || s[i] > '9' {
+				return false
+			}
+		}
+		return true
+	}
+
+	if language = subtags[0]; len(language) < 2 || len(language) > 3 || !isLetters(language) {
+		return "", "", ""
+	}
+	language = strings.ToLower(language)
+
+	subtags = subtags[1:]
+	if len(subtags) > 0 && len(subtags[0]) == 4 && isLetters(subtags[0]) {
+		script = strings.ToUpper(subtags[0][:1]) + strings.ToLower(subtags[0][1:])
+		subtags = subtags[1:]
+	}
+
+	switch {
+	case len(subtags) == 0:
+		return language, script, ""
+	case len(subtags) > 1:
+		return "", "", ""
+	case len(subtags[0]) == 2 && isLetters(subtags[0]):
+		return language, script, strings.ToUpper(subtags[0])
+	case len(subtags[0]) == 3 && isDigits(subtags[0]):
+		return language, script, subtags[0]
+	default:
+		return "", "", ""
+	}
+}
+
+/*
+joinLocaleName returns a locale name that is built from passed subtags,
+separated by "_". Empty script or region is skipped.
+*/
+func joinLocaleName(language, script, region string) string {
+	name := language
+	if script != "" {
+		name += "_" + script
+	}
+	if region != "" {
+		name += "_" + region
+	}
+	return name
+}
+
+/*
+normalizeLocaleName returns s converted to the canonical locale name
+(see parseLocaleName()), meaning the language part is lower cased,
+the script part is title cased, the region part is upper cased
+and "-" separator is replaced by "_" (e.g: "en-us" -> "en_US",
+"ZH-HANS-CN" -> "zh_Hans_CN", "EN" -> "en").
+Leading and trailing spaces are ignored.
+s is returned as is (w/o spaces), if it's not a locale name in any case.
+*/
+func normalizeLocaleName(s string) string {
+	if language, script, region := parseLocaleName(s); language != "" {
+		return joinLocaleName(language, script, region)
+	}
+	return strings.TrimSpace(s)
+}
+
+/*
+parseAcceptLanguage returns the language ranges of passed "Accept-Language"
+HTTP header value (e.g: "en-US,en;q=0.9,ru;q=0.8") in the order of preference,
+meaning sorted by their quality values (stable, so the order of equal ones
+is kept). Wildcard "*" and ranges with zero or malformed quality are skipped.
+*/
+func parseAcceptLanguage(s string) []string {
+
+	type languageRange struct {
+		name    string
+		quality float64
+	}
+
+	var ranges []languageRange
+
+	for _, part := range strings.Split(s, ",") {
+		name, params := part, ""
+		if idx := strings.IndexByte(part, ';'); idx != -1 {
+			name, params = part[:idx], part[idx+1:]
+		}
+
+		name, quality := strings.TrimSpace(name), 1.0
+		if name == "" || name == "*" {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				if quality, err = strconv.ParseFloat(param[2:], 64); err != nil {
+					quality = 0
+				}
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, languageRange{name, quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	names := make([]string, len(ranges))
+	for i := range ranges {
+		names[i] = ranges[i].name
+	}
+
+	return names
+}
+
+/*
+collapseSpaces returns s with each run of whitespaces (see unicode.IsSpace())
+replaced by one space (e.g: "Hello,\n  world" -> "Hello, world").
+s is returned as is, if there is nothing to collapse.
+*/
+func collapseSpaces(s string) string {
+
+	var (
+		b         strings.Builder
+		wasSpace  bool
+		collapsed bool
+	)
+
+	b.Grow(len(s))
+	for _, r := range s {
+		isSpace := unicode.IsSpace(r)
+		switch {
+		case isSpace && wasSpace:
+			collapsed = true
+		case isSpace:
+			collapsed = collapsed || r != ' '
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(r)
+		}
+		wasSpace = isSpace
+	}
+
+	if !collapsed {
+		return s
+	}
+	return b.String()
+}
+
+/*
+humanizeKey returns s as a human readable words separated by spaces,
+each starting with an upper case letter (e.g: "FileOpen", "file_open",
+"file-open" -> "File Open"). Words are separated by "_", "-", ".", spaces
+and case boundaries. Upper case abbreviations are kept as is
+(e.g: "HTTPServer" -> "HTTP Server").
+*/
+func humanizeKey(s string) string {
+
+	var (
+		runes     = []rune(s)
+		b         strings.Builder
+		wordStart = true
+	)
+
+	b.Grow(len(s) + 4)
+
+	for i, r := range runes {
+
+		if r == '_' || r == '-' || r == '.' || unicode.IsSpace(r) {
+			wordStart = true
+			continue
+		}
+
+		// Case boundary: "fileOpen" or "HTTPServer".
+		if !wordStart && unicode.IsUpper(r) &&
+			(!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+
+			wordStart = true
+		}
+
+		if wordStart {
+			if b.Len() != 0 {
+				b.WriteByte(' ')
+			}
+			r = unicode.ToUpper(r)
+			wordStart = false
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
 }
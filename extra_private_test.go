@@ -0,0 +1,159 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"testing"
+)
+
+func TestClientLCCasing(t *testing.T) {
+
+	var c Client
+
+	for _, localeName := range []string{"en_US", "sr_Latn_RS", "ru"} {
+		if err := c.AddLocale(localeName, map[string]interface{}{"title": "Title"}); err.IsNotNil() {
+			t.Fatalf("failed to add %s", localeName)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"en_US", "en_US"},
+		{"en-us", "en_US"},
+		{"EN_US", "en_US"},
+		{"En_Us", "en_US"},
+		{"eN-uS", "en_US"},
+		{" en_US ", "en_US"},
+		{"sr-latn-rs", "sr_Latn_RS"},
+		{"SR_LATN_RS", "sr_Latn_RS"},
+		{"RU", "ru"},
+		{"de_DE", ""},
+	}
+
+	for _, test := range tests {
+		localeName := ""
+		if loc := c.LC(test.name); loc != nil {
+			localeName = loc.name
+		}
+		if localeName != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, localeName, test.expected)
+		}
+	}
+}
+
+func TestClientLCSiblingLanguageFallback(t *testing.T) {
+
+	var c Client
+
+	for _, localeName := range []string{"pt_PT", "pt_AO", "es_AR", "es_ES", "en_US"} {
+		if err := c.AddLocale(localeName, map[string]interface{}{"title": "Title"}); err.IsNotNil() {
+			t.Fatalf("failed to add %s", localeName)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		allow    bool
+		priority []string
+		expected string
+	}{
+		{"pt_BR", false, nil, ""},
+		{"pt_BR", true, nil, "pt_AO"},
+		{"pt_BR", true, []string{"pt_PT"}, "pt_PT"},
+		{"pt-br", true, []string{"es_ES", "pt_PT"}, "pt_PT"},
+		{"es_MX", true, nil, "es_AR"},
+		{"es_MX", true, []string{"es_ES"}, "es_ES"},
+		{"es_MX", true, []string{"es_CL", "es_ES"}, "es_ES"},
+		{"pt_PT", true, []string{"pt_AO"}, "pt_PT"},
+		{"en_GB", true, nil, "en_US"},
+		{"de_DE", true, nil, ""},
+	}
+
+	for _, test := range tests {
+		c.SetAllowSiblingLanguageFallback(test.allow)
+		c.SetSiblingPriority(test.priority...)
+
+		localeName := ""
+		if loc := c.LC(test.name); loc != nil {
+			localeName = loc.name
+		}
+		if localeName != test.expected {
+			t.Errorf("%s (allow: %t, priority: %v): %q, expected %q",
+				test.name, test.allow, test.priority, localeName, test.expected)
+		}
+	}
+}
+
+func TestHumanizeKey(t *testing.T) {
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"FileOpen", "File Open"},
+		{"fileOpen", "File Open"},
+		{"file_open", "File Open"},
+		{"file-open", "File Open"},
+		{"file.open", "File Open"},
+		{"file  open", "File Open"},
+		{"__file__open__", "File Open"},
+		{"HTTPServer", "HTTP Server"},
+		{"parseHTTP", "Parse HTTP"},
+		{"HTTP", "HTTP"},
+		{"ID", "ID"},
+		{"userID", "User ID"},
+		{"Open2FA", "Open2 FA"},
+		{"привет_мир", "Привет Мир"},
+		{"ПриветМир", "Привет Мир"},
+		{"a", "A"},
+		{"___", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if humanized := humanizeKey(test.key); humanized != test.expected {
+			t.Errorf("%q: %q, expected %q", test.key, humanized, test.expected)
+		}
+	}
+}
+
+func TestLocaleTrMissingKeyHumanize(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{"title": "Title"}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	loc := c.LC("en_US")
+
+	tests := []struct {
+		name     string
+		humanize bool
+		leaf     bool
+		key      string
+		expected string
+	}{
+		{"disabled", false, false, "Menu/FileOpen", sptr(_SPTR_TRANSLATION_NOT_FOUND, "Menu/FileOpen")},
+		{"humanized leaf", true, false, "Menu/FileOpen", "File Open"},
+		{"humanized flat key", true, false, "save_as_draft", "Save As Draft"},
+		{"not humanizable leaf", true, false, "Menu/___", sptr(_SPTR_TRANSLATION_NOT_FOUND, "Menu/___")},
+		{"not humanizable leaf, fallback to leaf", true, true, "Menu/___", "___"},
+		{"raw leaf", false, true, "Menu/FileOpen", "FileOpen"},
+		{"found phrase", true, true, "title", "Title"},
+	}
+
+	for _, test := range tests {
+		c.SetMissingKeyHumanize(test.humanize)
+		c.SetMissingKeyFallbackToLeaf(test.leaf)
+
+		if translated := loc.Tr(test.key, nil); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+	}
+}
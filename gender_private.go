@@ -0,0 +1,61 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"fmt"
+	"strings"
+)
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	/*
+	There are grammatical genders.
+	A translation key may point to the node that contains language phrases
+	for some of these genders as its keys, like:
+
+	        Invited:
+	          male: "{{name}} пригласил вас"
+	          female: "{{name}} пригласила вас"
+	          neutral: "{{name}} пригласило вас"
+
+	_GENDER_NEUTRAL is used as a fallback, if the phrase for the requested gender
+	is missing. Then the first available one of _GENDER_MALE, _GENDER_FEMALE is used.
+	*/
+	_GENDER_MALE    = "male"
+	_GENDER_FEMALE  = "female"
+	_GENDER_NEUTRAL = "neutral"
+
+	/*
+	_GENDER_ARG is a name of argument the requested gender is passed by.
+	*/
+	_GENDER_ARG = "gender"
+)
+
+var (
+	/*
+	genderFallbacks is an order in which the gender forms are tried,
+	if there is no phrase for the requested gender.
+	*/
+	genderFallbacks = [...]string{_GENDER_NEUTRAL, _GENDER_MALE, _GENDER_FEMALE}
+)
+
+/*
+genderName returns the name of gender passed as _GENDER_ARG argument,
+trimmed and lower cased (e.g: "Female" -> "female").
+The argument might be a string or a fmt.Stringer.
+Returns an empty string if it's neither of them or is empty.
+*/
+func genderName(arg interface{}) string {
+	switch gender := arg.(type) {
+	case string:
+		return strings.ToLower(strings.TrimSpace(gender))
+	case fmt.Stringer:
+		return strings.ToLower(strings.TrimSpace(gender.String()))
+	default:
+		return ""
+	}
+}
@@ -1,11 +1,12 @@
 module github.com/qioalice/privet/v2
 
-go 1.15
+go 1.16
 
 require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.1
 	github.com/pelletier/go-toml v1.8.1
 	github.com/qioalice/ekago/v2 v2.9.6
+	golang.org/x/text v0.3.3
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 )
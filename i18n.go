@@ -6,23 +6,35 @@
 package privet
 
 import (
+	"context"
+	"io"
+
 	"github.com/qioalice/ekago/v2/ekaerr"
 )
 
 //goland:noinspection GoSnakeCaseUsage
 const (
 	DEFAULT_DELIMITER byte = '/'
+
+	DEFAULT_ARRAY_SEPARATOR = "\n"
 )
 
 /*
+Source is an alias for Client.Source() of the package's default Client.
+See that method for more details.
 
+Returns nil if sources are counted successfully (calling Throw() on nil *ekaerr.Error
+is a no-op), so it's safe to call it before any locale is loaded.
 */
 func Source(args ...interface{}) *ekaerr.Error {
-	return defaultClient.source(args).Throw()
+	return defaultClient.source(args, nil).Throw()
 }
 
 /*
+Load is an alias for Client.Load() of the package's default Client.
+See that method for more details.
 
+Returns nil if locales are loaded successfully (or there is nothing new to load).
 */
 func Load() *ekaerr.Error {
 	return defaultClient.load().Throw()
@@ -44,19 +56,529 @@ You may change that behaviour using:
  2. Config.LCNotFoundLocaleAsNil set to true (false by default)
     if you want to get nil Locale if Locale with requested name not found
     (even if any Locale is marked as default).
+
+It's safe to call it before Load(). nil is returned then,
+and it's also safe to call Locale.Tr() of nil Locale (a special string is returned).
 */
 func LC(name string) *Locale {
 	return defaultClient.LC(name)
 }
 
+/*
+Default is an alias for Client.Default() of the package's default Client.
+See that method for more details.
+
+It's safe to call it before Load(). nil is returned then.
+*/
 func Default() *Locale {
 	return defaultClient.Default()
 }
 
+/*
+DefaultClient returns the package's default Client, the package level functions
+(Source(), Load(), LC(), Tr(), etc) are aliases for the methods of.
+It allows to use the methods that have no alias, or to pass the default Client
+where *Client is expected.
+*/
+func DefaultClient() *Client {
+	return &defaultClient
+}
+
 /*
 Tr is an alias for LC(localeName).Tr(key, args).
 See LC() function and Locale.Tr() method for more details.
+
+It's safe to call it before Load(). The special string
+"i18nErr: LocaleIsNil. Key: <key>" is returned then.
 */
 func Tr(localeName, key string, args Args) string {
 	return defaultClient.LC(localeName).Tr(key, args)
 }
+
+/*
+TrChain is an alias for Client.TrChain(key, args, localeNames...).
+See that method for more details.
+*/
+func TrChain(key string, args Args, localeNames ...string) string {
+	return defaultClient.TrChain(key, args, localeNames...)
+}
+
+/*
+FormatStats is an alias for Client.FormatStats().
+See that method for more details.
+*/
+func FormatStats() map[SourceItemType]int {
+	return defaultClient.FormatStats()
+}
+
+/*
+LastLoadReport is an alias for Client.LastLoadReport().
+See that method for more details.
+*/
+func LastLoadReport() *LoadReport {
+	return defaultClient.LastLoadReport()
+}
+
+/*
+SetMissingKeyFallbackToLeaf is an alias for Client.SetMissingKeyFallbackToLeaf().
+See that method for more details.
+*/
+func SetMissingKeyFallbackToLeaf(enable bool) {
+	defaultClient.SetMissingKeyFallbackToLeaf(enable)
+}
+
+/*
+SetContinueOnSourceError is an alias for Client.SetContinueOnSourceError().
+See that method for more details.
+*/
+func SetContinueOnSourceError(enable bool) {
+	defaultClient.SetContinueOnSourceError(enable)
+}
+
+/*
+SetCollapseEmptyKeySegments is an alias for Client.SetCollapseEmptyKeySegments().
+See that method for more details.
+*/
+func SetCollapseEmptyKeySegments(enable bool) {
+	defaultClient.SetCollapseEmptyKeySegments(enable)
+}
+
+/*
+SetSkipInvalidSources is an alias for Client.SetSkipInvalidSources().
+See that method for more details.
+*/
+func SetSkipInvalidSources(enable bool) {
+	defaultClient.SetSkipInvalidSources(enable)
+}
+
+/*
+SetPreprocessor is an alias for Client.SetPreprocessor().
+See that method for more details.
+*/
+func SetPreprocessor(fn Preprocessor) {
+	defaultClient.SetPreprocessor(fn)
+}
+
+/*
+RegisterInterpFunc is an alias for Client.RegisterInterpFunc().
+See that method for more details.
+*/
+func RegisterInterpFunc(name string, fn InterpFunc) {
+	defaultClient.RegisterInterpFunc(name, fn)
+}
+
+/*
+Snapshot is an alias for Client.Snapshot().
+See that method for more details.
+*/
+func Snapshot() map[string]map[string]string {
+	return defaultClient.Snapshot()
+}
+
+/*
+SetMaxSourceFileSize is an alias for Client.SetMaxSourceFileSize().
+See that method for more details.
+*/
+func SetMaxSourceFileSize(size int64) {
+	defaultClient.SetMaxSourceFileSize(size)
+}
+
+/*
+SetMaxDirectoryDepth is an alias for Client.SetMaxDirectoryDepth().
+See that method for more details.
+*/
+func SetMaxDirectoryDepth(depth int) {
+	defaultClient.SetMaxDirectoryDepth(depth)
+}
+
+/*
+SetPreserveKeyOrder is an alias for Client.SetPreserveKeyOrder().
+See that method for more details.
+*/
+func SetPreserveKeyOrder(enable bool) {
+	defaultClient.SetPreserveKeyOrder(enable)
+}
+
+/*
+SetTrimPhrases is an alias for Client.SetTrimPhrases().
+See that method for more details.
+*/
+func SetTrimPhrases(enable bool) {
+	defaultClient.SetTrimPhrases(enable)
+}
+
+/*
+SetCollapsePhraseSpaces is an alias for Client.SetCollapsePhraseSpaces().
+See that method for more details.
+*/
+func SetCollapsePhraseSpaces(enable bool) {
+	defaultClient.SetCollapsePhraseSpaces(enable)
+}
+
+/*
+SetStrictMetadata is an alias for Client.SetStrictMetadata().
+See that method for more details.
+*/
+func SetStrictMetadata(enable bool) {
+	defaultClient.SetStrictMetadata(enable)
+}
+
+/*
+SetCompilePhrases is an alias for Client.SetCompilePhrases().
+See that method for more details.
+*/
+func SetCompilePhrases(enable bool) {
+	defaultClient.SetCompilePhrases(enable)
+}
+
+/*
+SetMetadataLocaleKeys is an alias for Client.SetMetadataLocaleKeys().
+See that method for more details.
+*/
+func SetMetadataLocaleKeys(keys ...string) {
+	defaultClient.SetMetadataLocaleKeys(keys...)
+}
+
+/*
+GenerateKeyConstants is an alias for Client.GenerateKeyConstants().
+See that method for more details.
+*/
+func GenerateKeyConstants(pkgName string, w io.Writer) *ekaerr.Error {
+	return defaultClient.GenerateKeyConstants(pkgName, w).Throw()
+}
+
+/*
+SetContentResolveOrder is an alias for Client.SetContentResolveOrder().
+See that method for more details.
+*/
+func SetContentResolveOrder(types ...SourceItemType) *ekaerr.Error {
+	return defaultClient.SetContentResolveOrder(types...).Throw()
+}
+
+/*
+SetAllowSiblingLanguageFallback is an alias for Client.SetAllowSiblingLanguageFallback().
+See that method for more details.
+*/
+func SetAllowSiblingLanguageFallback(enable bool) {
+	defaultClient.SetAllowSiblingLanguageFallback(enable)
+}
+
+/*
+SetSiblingPriority is an alias for Client.SetSiblingPriority().
+See that method for more details.
+*/
+func SetSiblingPriority(localeNames ...string) {
+	defaultClient.SetSiblingPriority(localeNames...)
+}
+
+/*
+SourceFlat is an alias for Client.SourceFlat().
+See that method for more details.
+*/
+func SourceFlat(localeName string, flat map[string]string, delimiter byte) *ekaerr.Error {
+	return defaultClient.SourceFlat(localeName, flat, delimiter).Throw()
+}
+
+/*
+SetPruneEmptyNodes is an alias for Client.SetPruneEmptyNodes().
+See that method for more details.
+*/
+func SetPruneEmptyNodes(enable bool) {
+	defaultClient.SetPruneEmptyNodes(enable)
+}
+
+/*
+SetStrictKeyPath is an alias for Client.SetStrictKeyPath().
+See that method for more details.
+*/
+func SetStrictKeyPath(enable bool) {
+	defaultClient.SetStrictKeyPath(enable)
+}
+
+/*
+SourceWithOptions is an alias for Client.SourceWithOptions().
+See that method for more details.
+*/
+func SourceWithOptions(opts SourceOptions, args ...interface{}) *ekaerr.Error {
+	return defaultClient.SourceWithOptions(opts, args...).Throw()
+}
+
+/*
+KeyDelimiter is an alias for Client.KeyDelimiter().
+See that method for more details.
+*/
+func KeyDelimiter() byte {
+	return defaultClient.KeyDelimiter()
+}
+
+/*
+SetKeyTransform is an alias for Client.SetKeyTransform().
+See that method for more details.
+*/
+func SetKeyTransform(fn KeyTransform) {
+	defaultClient.SetKeyTransform(fn)
+}
+
+/*
+SetKeyTransformNodes is an alias for Client.SetKeyTransformNodes().
+See that method for more details.
+*/
+func SetKeyTransformNodes(enable bool) {
+	defaultClient.SetKeyTransformNodes(enable)
+}
+
+/*
+SetOnMissing is an alias for Client.SetOnMissing().
+See that method for more details.
+*/
+func SetOnMissing(fn OnMissing) {
+	defaultClient.SetOnMissing(fn)
+}
+
+/*
+SetSafePlaceholder is an alias for Client.SetSafePlaceholder().
+See that method for more details.
+*/
+func SetSafePlaceholder(placeholder string) {
+	defaultClient.SetSafePlaceholder(placeholder)
+}
+
+/*
+SetArraySeparator is an alias for Client.SetArraySeparator().
+See that method for more details.
+*/
+func SetArraySeparator(separator string) {
+	defaultClient.SetArraySeparator(separator)
+}
+
+/*
+SetIgnoreKeyPrefix is an alias for Client.SetIgnoreKeyPrefix().
+See that method for more details.
+*/
+func SetIgnoreKeyPrefix(prefixes ...string) {
+	defaultClient.SetIgnoreKeyPrefix(prefixes...)
+}
+
+/*
+AddLocale is an alias for Client.AddLocale().
+See that method for more details.
+*/
+func AddLocale(name string, content map[string]interface{}) *ekaerr.Error {
+	return defaultClient.AddLocale(name, content).Throw()
+}
+
+/*
+SetOnUnknownFilter is an alias for Client.SetOnUnknownFilter().
+See that method for more details.
+*/
+func SetOnUnknownFilter(fn OnUnknownFilter) {
+	defaultClient.SetOnUnknownFilter(fn)
+}
+
+/*
+ValidateVerbs is an alias for Client.ValidateVerbs().
+See that method for more details.
+*/
+func ValidateVerbs(base string) ([]VerbsMismatch, *ekaerr.Error) {
+	mismatches, err := defaultClient.ValidateVerbs(base)
+	return mismatches, err.Throw()
+}
+
+/*
+SourceDir is an alias for Client.SourceDir().
+See that method for more details.
+*/
+func SourceDir(path string, recursive bool) *ekaerr.Error {
+	return defaultClient.SourceDir(path, recursive).Throw()
+}
+
+/*
+SetMissingKeyHumanize is an alias for Client.SetMissingKeyHumanize().
+See that method for more details.
+*/
+func SetMissingKeyHumanize(enable bool) {
+	defaultClient.SetMissingKeyHumanize(enable)
+}
+
+/*
+ReloadFile is an alias for Client.ReloadFile().
+See that method for more details.
+*/
+func ReloadFile(path string) *ekaerr.Error {
+	return defaultClient.ReloadFile(path).Throw()
+}
+
+/*
+LocalesByLanguage is an alias for Client.LocalesByLanguage().
+See that method for more details.
+*/
+func LocalesByLanguage() map[string][]string {
+	return defaultClient.LocalesByLanguage()
+}
+
+/*
+Languages is an alias for Client.Languages().
+See that method for more details.
+*/
+func Languages() []string {
+	return defaultClient.Languages()
+}
+
+/*
+SetAutoDefaultLocale is an alias for Client.SetAutoDefaultLocale().
+See that method for more details.
+*/
+func SetAutoDefaultLocale(localeName string) {
+	defaultClient.SetAutoDefaultLocale(localeName)
+}
+
+/*
+SetRequireDefaultLocale is an alias for Client.SetRequireDefaultLocale().
+See that method for more details.
+*/
+func SetRequireDefaultLocale(enable bool) {
+	defaultClient.SetRequireDefaultLocale(enable)
+}
+
+/*
+SetNilArgRenderer is an alias for Client.SetNilArgRenderer().
+See that method for more details.
+*/
+func SetNilArgRenderer(fn NilArgRenderer) {
+	defaultClient.SetNilArgRenderer(fn)
+}
+
+/*
+SetTraceLoad is an alias for Client.SetTraceLoad().
+See that method for more details.
+*/
+func SetTraceLoad(enable bool) {
+	defaultClient.SetTraceLoad(enable)
+}
+
+/*
+SetOnTrace is an alias for Client.SetOnTrace().
+See that method for more details.
+*/
+func SetOnTrace(fn OnTrace) {
+	defaultClient.SetOnTrace(fn)
+}
+
+/*
+WhoHas is an alias for Client.WhoHas().
+See that method for more details.
+*/
+func WhoHas(key string) (has, missing []string) {
+	return defaultClient.WhoHas(key)
+}
+
+/*
+VerifySources is an alias for Client.VerifySources().
+See that method for more details.
+*/
+func VerifySources() ([]string, *ekaerr.Error) {
+	changed, err := defaultClient.VerifySources()
+	return changed, err.Throw()
+}
+
+/*
+PluralCategory returns the CLDR plural category ("zero", "one", "two", "few",
+"many" or "other") that is selected for the integer n by the plural rules
+of the language of passed locale name (e.g: "few" for "ru_RU" and 3,
+but "other" for "en_US" and 3). The name may be in any case and "-" might be used
+as a separator (e.g: "ru-ru").
+
+Only integer rules are supported. English rules are used for unknown languages.
+It's the same selection as Locale.TrCount() and Locale.Tr() with "count" argument do.
+*/
+func PluralCategory(localeName string, n int64) string {
+	return pluralCategory(normalizeLocaleName(localeName), n)
+}
+
+/*
+SetKeyDelimiters is an alias for Client.SetKeyDelimiters().
+See that method for more details.
+*/
+func SetKeyDelimiters(delimiters ...byte) {
+	defaultClient.SetKeyDelimiters(delimiters...)
+}
+
+/*
+SetFallback is an alias for Client.SetFallback().
+See that method for more details.
+*/
+func SetFallback(localeName string, fallbacks ...string) {
+	defaultClient.SetFallback(localeName, fallbacks...)
+}
+
+/*
+SetFallbackToDefault is an alias for Client.SetFallbackToDefault().
+See that method for more details.
+*/
+func SetFallbackToDefault(enable bool) {
+	defaultClient.SetFallbackToDefault(enable)
+}
+
+/*
+SetOnWatchError is an alias for Client.SetOnWatchError().
+See that method for more details.
+*/
+func SetOnWatchError(fn OnWatchError) {
+	defaultClient.SetOnWatchError(fn)
+}
+
+/*
+Watch is an alias for Client.Watch().
+See that method for more details.
+*/
+func Watch(ctx context.Context) *ekaerr.Error {
+	return defaultClient.Watch(ctx).Throw()
+}
+
+/*
+SetMissingKeyHandler is an alias for Client.SetMissingKeyHandler().
+See that method for more details.
+*/
+func SetMissingKeyHandler(fn MissingKeyHandler) {
+	defaultClient.SetMissingKeyHandler(fn)
+}
+
+/*
+SetDebugInterpolation is an alias for Client.SetDebugInterpolation().
+See that method for more details.
+*/
+func SetDebugInterpolation(enable bool) {
+	defaultClient.SetDebugInterpolation(enable)
+}
+
+/*
+SetOnInterpolationIssue is an alias for Client.SetOnInterpolationIssue().
+See that method for more details.
+*/
+func SetOnInterpolationIssue(fn OnInterpolationIssue) {
+	defaultClient.SetOnInterpolationIssue(fn)
+}
+
+/*
+Reload is an alias for Client.Reload().
+See that method for more details.
+*/
+func Reload() *ekaerr.Error {
+	return defaultClient.Reload().Throw()
+}
+
+/*
+Unload is an alias for Client.Unload().
+See that method for more details.
+*/
+func Unload() *ekaerr.Error {
+	return defaultClient.Unload().Throw()
+}
+
+/*
+Merge is an alias for Client.Merge().
+See that method for more details.
+*/
+func Merge(other *Client, overwrite bool) *ekaerr.Error {
+	return defaultClient.Merge(other, overwrite).Throw()
+}
@@ -0,0 +1,86 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+)
+
+func TestThrowNil(t *testing.T) {
+
+	var err *ekaerr.Error
+	if err.Throw().IsNotNil() {
+		t.Error("Throw() of nil error: expected nil")
+	}
+
+	defaultClient = Client{}
+	defer func() { defaultClient = Client{} }()
+
+	if err := AddLocale("en_US", map[string]interface{}{"title": "Title"}); err.IsNotNil() {
+		t.Error("AddLocale(): expected nil error")
+	}
+	if err := Load(); err.IsNotNil() {
+		t.Error("Load() of loaded locales: expected nil error")
+	}
+	if err := Reload(); err.IsNotNil() {
+		t.Error("Reload(): expected nil error")
+	}
+	if _, err := ValidateVerbs("en_US"); err.IsNotNil() {
+		t.Error("ValidateVerbs(): expected nil error")
+	}
+	if err := Unload(); err.IsNotNil() {
+		t.Error("Unload(): expected nil error")
+	}
+}
+
+func TestDefaultClientNotLoaded(t *testing.T) {
+
+	defaultClient = Client{}
+	defer func() { defaultClient = Client{} }()
+
+	notLoaded := sptr(_SPTR_LOCALE_IS_NIL, "title")
+
+	tests := []struct {
+		name     string
+		actual   interface{}
+		expected interface{}
+	}{
+		{"LC", LC("en_US") == nil, true},
+		{"Default", Default() == nil, true},
+		{"DefaultClient", DefaultClient() == &defaultClient, true},
+		{"Tr", Tr("en_US", "title", nil), notLoaded},
+		{"Tr with args", Tr("en_US", "title", Args{"name": "Bob"}), notLoaded},
+		{"Default Tr", Default().Tr("title", nil), notLoaded},
+		{"TrChain", TrChain("title", nil, "en_US", "ru_RU") != "", true},
+		{"FormatStats", len(FormatStats()), 0},
+		{"LastLoadReport", LastLoadReport() == nil, true},
+		{"Snapshot", len(Snapshot()), 0},
+		{"KeyDelimiter", KeyDelimiter(), byte(DEFAULT_DELIMITER)},
+		{"LocalesByLanguage", len(LocalesByLanguage()), 0},
+		{"Languages", len(Languages()), 0},
+		{"WhoHas", func() bool { has, missing := WhoHas("title"); return has == nil && missing == nil }(), true},
+		{"PluralCategory", PluralCategory("en_US", 1), _PLURAL_ONE},
+		{"Load", Load().IsNotNil(), true},
+		{"Reload", Reload().IsNotNil(), true},
+		{"ReloadFile", ReloadFile("en_US.yaml").IsNotNil(), true},
+		{"Unload", Unload().IsNotNil(), true},
+		{"ValidateVerbs", func() bool { _, err := ValidateVerbs("en_US"); return err.IsNotNil() }(), true},
+		{"VerifySources", func() bool { _, err := VerifySources(); return err.IsNotNil() }(), true},
+		{"GenerateKeyConstants", GenerateKeyConstants("i18n", new(bytes.Buffer)).IsNotNil(), true},
+		{"Merge", Merge(new(Client), false).IsNotNil(), true},
+		{"Watch", Watch(context.Background()).IsNotNil(), true},
+	}
+
+	for _, test := range tests {
+		if test.actual != test.expected {
+			t.Errorf("%s: %v, expected %v", test.name, test.actual, test.expected)
+		}
+	}
+}
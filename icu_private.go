@@ -0,0 +1,264 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/qioalice/ekago/v2/ekastr"
+)
+
+type (
+	/*
+	icuFormatter is a helper tool to evaluate the subset of ICU MessageFormat
+	of translation phrase. It's a recursive descent parser that writes
+	the result to the builder while walking over src.
+
+	Supported syntax:
+
+	 - "{name}", replaced by the selector's value with that name,
+	 - "{name, select, key1 {...} key2 {...} other {...}}",
+	 - "{name, plural, =0 {...} one {...} other {...}}",
+	   where "#" inside the selected message is replaced by the selector's value
+	   formatted using the Locale's number formatting rules.
+
+	Sub-messages may contain nested arguments.
+	Privet's interpolation verbs "{{name}}" are kept untouched.
+	ICU's apostrophe quoting is not supported.
+	*/
+	icuFormatter struct {
+		loc       *Locale
+		selectors map[string]interface{}
+		src       string
+		pos       int
+	}
+)
+
+/*
+formatICU evaluates ICU MessageFormat subset (see icuFormatter) of passed phrase
+using passed selectors. Returns the phrase as is, if it's malformed.
+*/
+func formatICU(loc *Locale, phrase string, selectors map[string]interface{}) string {
+
+	if strings.IndexByte(phrase, '{') == -1 {
+		return phrase
+	}
+
+	f := icuFormatter{
+		loc:       loc,
+		selectors: selectors,
+		src:       phrase,
+	}
+
+	var b strings.Builder
+	b.Grow(len(phrase))
+
+	if !f.message(&b, "") || f.pos != len(f.src) {
+		return phrase
+	}
+
+	return b.String()
+}
+
+/*
+message writes the evaluated message starting from the current position
+till the end of src or till unpaired '}', that is not consumed.
+hash is a string "#" is replaced by, "#" is kept as is if hash is empty.
+Returns false if message is malformed.
+*/
+func (f *icuFormatter) message(b *strings.Builder, hash string) bool {
+
+	for f.pos < len(f.src) {
+		switch c := f.src[f.pos]; {
+
+		case c == '}':
+			return true
+
+		case c == '{' && strings.HasPrefix(f.src[f.pos:], "{{"):
+			// Privet's interpolation verb. Keep it untouched.
+			end := strings.Index(f.src[f.pos:], "}}")
+			if end == -1 {
+				return false
+			}
+			b.WriteString(f.src[f.pos:f.pos+end+2])
+			f.pos += end + 2
+
+		case c == '{':
+			f.pos++
+			if !f.argument(b, hash) {
+				return false
+			}
+
+		case c == '#' && hash != "":
+			b.WriteString(hash)
+			f.pos++
+
+		default:
+			b.WriteByte(c)
+			f.pos++
+		}
+	}
+
+	return true
+}
+
+/*
+argument writes the evaluated argument the current position is inside
+(right after '{'), consuming it including the closing '}'.
+Returns false if argument is malformed.
+*/
+func (f *icuFormatter) argument(b *strings.Builder, hash string) bool {
+
+	name, delimiter := f.token(",}")
+	if name == "" || delimiter == 0 {
+		return false
+	}
+
+	value, found := f.selectors[name]
+
+	if delimiter == '}' {
+		if found {
+			b.WriteString(ekastr.ToString(value))
+		} else {
+			b.WriteString("{" + name + "}")
+		}
+		return true
+	}
+
+	typ, delimiter := f.token(",")
+	if delimiter == 0 {
+		return false
+	}
+
+	var (
+		selected   []string // option's keys in priority order
+		hashInside = hash
+	)
+
+	switch typ {
+
+	case "select":
+		if found {
+			selected = append(selected, ekastr.ToString(value))
+		}
+
+	case "plural":
+		n, isNumber := icuNumber(value)
+		if found && isNumber {
+			selected = append(selected,
+				"=" + strconv.FormatInt(n, 10), pluralCategory(f.loc.name, n))
+			hashInside = formatInteger(f.loc.name, n)
+		}
+
+	default:
+		return false
+	}
+
+	selected = append(selected, _PLURAL_OTHER)
+	return f.options(b, selected, hashInside)
+}
+
+/*
+options parses options of select or plural argument (like "key {message} ..."),
+writes the message of the option which key is the first one in selected
+and consumes the closing '}' of the argument.
+Returns false if options are malformed or there is no option to select.
+*/
+func (f *icuFormatter) options(b *strings.Builder, selected []string, hash string) bool {
+
+	var (
+		messages = make(map[string]string, 4)
+		sub      strings.Builder
+	)
+
+	for {
+		key, delimiter := f.token("{}")
+		switch {
+
+		case delimiter == '}' && key == "":
+			for _, key = range selected {
+				if message, found := messages[key]; found {
+					b.WriteString(message)
+					return true
+				}
+			}
+			return false
+
+		case delimiter != '{' || key == "":
+			return false
+		}
+
+		sub.Reset()
+		if !f.message(&sub, hash) || f.pos == len(f.src) {
+			return false
+		}
+		f.pos++ // consume '}' of option's message
+
+		if _, found := messages[key]; !found {
+			messages[key] = sub.String()
+		}
+	}
+}
+
+/*
+token returns a trimmed string from the current position till the first
+of passed delimiters (that is also returned and consumed).
+The returned delimiter is 0 if there is no any of them till the end of src.
+*/
+func (f *icuFormatter) token(delimiters string) (string, byte) {
+
+	idx := strings.IndexAny(f.src[f.pos:], delimiters)
+	if idx == -1 {
+		return "", 0
+	}
+
+	token := strings.TrimSpace(f.src[f.pos:f.pos+idx])
+	delimiter := f.src[f.pos+idx]
+
+	f.pos += idx + 1
+	return token, delimiter
+}
+
+/*
+icuNumber converts passed value of any integer or float type
+(or a string representation of integer) to the int64.
+The 2nd returned value is false if value is not a number.
+*/
+func icuNumber(value interface{}) (int64, bool) {
+
+	switch n := value.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	case float32:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	case string:
+		i64, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+		return i64, err == nil
+	default:
+		return 0, false
+	}
+}
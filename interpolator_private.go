@@ -6,23 +6,64 @@
 package privet
 
 import (
+	"bytes"
+	"reflect"
+	"sort"
 	"strings"
+	"sync/atomic"
 
 	"github.com/qioalice/ekago/v2/ekastr"
 )
 
+//goland:noinspection GoSnakeCaseUsage
+const (
+	/*
+	_VERB_SPEC_NUMBER is a format spec of the "{{<name>:number}}" verb,
+	the numeric argument is formatted with the groups of thousands
+	and decimal separators of the Locale's language (e.g: "1,000,000").
+	*/
+	_VERB_SPEC_NUMBER = "number"
+
+	/*
+	_VERB_ESCAPED_OPEN is an escaped "{{" sequence. It's written as a literal "{{"
+	and is never treated as a beginning of the interpolation verb.
+	*/
+	_VERB_ESCAPED_OPEN = "{{{{"
+)
+
+var (
+	/*
+	escapedOpen is _VERB_ESCAPED_OPEN as []byte to avoid conversion for each lookup.
+	*/
+	escapedOpen = []byte(_VERB_ESCAPED_OPEN)
+)
+
 type (
 	/*
 	interpolator is a helper tool to interpolate a string.
 	It's a worker that takes a values from args by their keys and substitute
 	them to the rem (it's a string as []byte) instead of the same name
-	interpolation verbs using provided strings.Builder to accumulate result
+	interpolation verbs using buf to accumulate result
 	and do interpolation the most efficient way.
 	*/
 	interpolator struct {
+		loc     *Locale
+		key     string // translation key of the phrase, for diagnostics only
 		args    Args
-		builder strings.Builder
+		posArgs []interface{} // positional arguments, see Locale.Trf()
+		buf     []byte
 		rem     []byte
+		tokens  []phraseToken        // tokens of rem, nil if the phrase is not compiled
+		issues  *interpolationIssues // nil if Config.DebugInterpolation is disabled
+	}
+
+	/*
+	interpolationIssues accumulates the diagnostics of one interpolation,
+	if Config.DebugInterpolation is enabled. See Client.SetDebugInterpolation().
+	*/
+	interpolationIssues struct {
+		missing []string            // names of arguments verbs refer to, but absent
+		used    map[string]struct{} // names of used arguments
 	}
 )
 
@@ -32,24 +73,312 @@ that is called when a interpolation verb is found.
 
 Writes corresponding argument from args if it exists,
 or keeps verb untouched and writes it as just text.
+Slices and arrays are joined by the Locale's list formatting rules
+(e.g: "Alice, Bob, and Carol"), see formatList().
+nil arguments (including typed nil pointers, maps, slices, etc)
+are rendered by NilArgRenderer (see Client.SetNilArgRenderer()),
+an empty string is written by default.
+
+If there is no argument with the verb's name and the verb has "func:arg" shape,
+the result of the registered InterpFunc "func" (see Client.RegisterInterpFunc())
+called with the "arg" argument is written.
+The verb is kept untouched if either InterpFunc or argument is not found.
+
+If the verb has "arg:number" shape, the numeric argument is written
+with the groups of thousands and decimal separators of the Locale's language
+(e.g: "1,000,000" for "en_US", "1 000 000" for "ru_RU"), see formatNumber().
+Non numeric argument is written as is.
+
+If the verb has "arg|filter1|filter2" shape, see writeFiltered().
 */
 func (ir *interpolator) cbFoundVerb(p []byte) {
 	// guarantees that p's len >= 4
-	if arg, found := ir.args[ekastr.B2S(p[2:len(p)-2])]; found {
-		_, _ = ir.builder.WriteString(ekastr.ToString(arg))
-	} else {
-		_, _ = ir.builder.Write(p)
+	// Spaces around the verb's name are allowed: "{{ name }}".
+	ir.writeVerb(p, strings.TrimSpace(ekastr.B2S(p[2:len(p)-2])))
+}
+
+/*
+writeVerb is the same as cbFoundVerb() but takes the verb w/o braces
+and surrounding spaces, that is already extracted from p.
+*/
+func (ir *interpolator) writeVerb(p []byte, verb string) {
+
+	if arg, found := ir.arg(verb); found {
+		if isNilArg(arg) {
+			ir.writeString(ir.loc.owner.renderNilArg(verb))
+		} else if list, isList := formatList(ir.loc, arg); isList {
+			ir.writeString(list)
+		} else {
+			ir.writeString(ekastr.ToString(arg))
+		}
+		return
+	}
+
+	if idx := strings.IndexByte(verb, '|'); idx > 0 {
+		ir.writeFiltered(p, verb, idx)
+		return
+	}
+
+	if idx := strings.IndexByte(verb, ':'); idx > 0 && strings.TrimSpace(verb[idx+1:]) == _VERB_SPEC_NUMBER {
+		if arg, found := ir.arg(strings.TrimSpace(verb[:idx])); found {
+			if formattedNumber, isNumber := formatNumber(ir.loc.name, arg); isNumber {
+				ir.writeString(formattedNumber)
+			} else {
+				ir.writeString(ekastr.ToString(arg))
+			}
+			return
+		}
+		ir.reportMissing(verb)
+		ir.buf = append(ir.buf, p...)
+		return
+	}
+
+	if idx := strings.IndexByte(verb, ':'); idx > 0 {
+		fnName := strings.TrimSpace(verb[:idx])
+		fn := ir.loc.owner.getInterpFunc(fnName)
+		arg, found := ir.arg(strings.TrimSpace(verb[idx+1:]))
+
+		switch {
+		case fn == nil:
+			// It's the function that is missing, not the argument.
+			ir.loc.owner.callOnUnknownFilter(ir.loc.name, verb, fnName)
+			if !found {
+				ir.reportMissing(verb)
+			}
+			ir.buf = append(ir.buf, p...)
+			return
+
+		case found:
+			ir.writeString(fn(arg))
+			return
+		}
+	}
+
+	ir.reportMissing(verb)
+	ir.buf = append(ir.buf, p...)
+}
+
+/*
+writeFiltered writes the argument of the verb of "arg|filter1|filter2" shape
+(idx is an index of the first '|'), passing it through the registered InterpFunc
+with the filters' names (see Client.RegisterInterpFunc()) from left to right:
+the argument is passed to the first one, its result is passed to the next one, etc.
+
+If any filter is not registered, OnUnknownFilter callback is called
+(see Client.SetOnUnknownFilter()) and p is written as is.
+p is also written as is if there is no such argument.
+The argument is looked up first anyway, so it's never reported as unused
+(see finishIssues()) because of unknown filter.
+*/
+func (ir *interpolator) writeFiltered(p []byte, verb string, idx int) {
+
+	arg, found := ir.arg(strings.TrimSpace(verb[:idx]))
+
+	filterNames := strings.Split(verb[idx+1:], "|")
+	filters := make([]InterpFunc, len(filterNames))
+
+	for i, filterName := range filterNames {
+		filterName = strings.TrimSpace(filterName)
+		if filters[i] = ir.loc.owner.getInterpFunc(filterName); filters[i] == nil {
+			ir.loc.owner.callOnUnknownFilter(ir.loc.name, verb, filterName)
+			if !found {
+				ir.reportMissing(verb)
+			}
+			ir.buf = append(ir.buf, p...)
+			return
+		}
+	}
+
+	if !found {
+		ir.reportMissing(verb)
+		ir.buf = append(ir.buf, p...)
+		return
+	}
+
+	for _, filter := range filters {
+		arg = filter(arg)
+	}
+
+	ir.writeString(ekastr.ToString(arg))
+}
+
+/*
+arg returns an argument from args by its name.
+
+If the name consists of digits only (e.g: "0", "1") and there are positional
+arguments (see Locale.Trf()), it's an index of positional argument.
+
+If there is no argument with exactly that name and the name is dotted
+(e.g: "user.name"), it's treated as a path: the first segment is an argument name,
+and each next one is either a key of the nested map with string keys
+or a field name of the nested struct (pointers are dereferenced).
+The 2nd returned value is false if any of path's segment is not found.
+*/
+func (ir *interpolator) arg(name string) (interface{}, bool) {
+
+	if idx, isIndex := positionalArgIndex(name); isIndex && ir.posArgs != nil {
+		if idx < len(ir.posArgs) {
+			return ir.posArgs[idx], true
+		}
+		return nil, false
+	}
+
+	if arg, found := ir.args[name]; found || strings.IndexByte(name, '.') == -1 {
+		if found && ir.issues != nil {
+			ir.issues.used[name] = struct{}{}
+		}
+		return arg, found
+	}
+
+	segments := strings.Split(name, ".")
+
+	arg, found := ir.args[segments[0]]
+	if found && ir.issues != nil {
+		ir.issues.used[segments[0]] = struct{}{}
+	}
+	for i, n := 1, len(segments); i < n && found; i++ {
+		arg, found = argField(arg, segments[i])
+	}
+
+	return arg, found
+}
+
+/*
+positionalArgIndex returns an index of positional argument the name refers to,
+if the name consists of digits only (e.g: "0", "12").
+The 2nd returned value is false otherwise.
+*/
+func positionalArgIndex(name string) (int, bool) {
+
+	if name == "" || len(name) > 9 {
+		return 0, false
+	}
+
+	idx := 0
+	for i, n := 0, len(name); i < n; i++ {
+		if name[i] < '0' || name[i] > '9' {
+			return 0, false
+		}
+		idx = idx*10 + int(name[i]-'0')
 	}
+
+	return idx, true
+}
+
+/*
+isNilArg reports whether arg is nil or a typed nil value
+of pointer, map, slice, interface, func or chan type.
+*/
+func isNilArg(arg interface{}) bool {
+
+	if arg == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(arg); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+/*
+argField returns a value of the map by the passed key, if arg is a map
+with string keys, or a value of the exported struct's field by the passed name,
+if arg is a struct (or a pointer to). The 2nd returned value is false otherwise
+or if there is no such key or field.
+*/
+func argField(arg interface{}, name string) (interface{}, bool) {
+
+	switch typedArg := arg.(type) {
+	case Args:
+		v, found := typedArg[name]
+		return v, found
+	case map[string]interface{}:
+		v, found := typedArg[name]
+		return v, found
+	case map[string]string:
+		v, found := typedArg[name]
+		return v, found
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	switch {
+
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+
+	case v.Kind() == reflect.Struct:
+		field, found := v.Type().FieldByName(name)
+		if !found || field.PkgPath != "" {
+			return nil, false
+		}
+		v = v.FieldByIndex(field.Index)
+
+	default:
+		return nil, false
+	}
+
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	return v.Interface(), true
+}
+
+/*
+phraseVerbs returns the names of interpolation verbs of passed phrase
+(see Locale.Verbs()) using the same verbs scanning interpolate() does.
+*/
+func phraseVerbs(phrase string) []string {
+
+	var verbs []string
+	interpolateEscaped(ekastr.S2B(phrase),
+		func(p []byte) {
+			verb := strings.TrimSpace(string(p[2:len(p)-2]))
+			for _, seenVerb := range verbs {
+				if seenVerb == verb {
+					return
+				}
+			}
+			verbs = append(verbs, verb)
+		},
+		func(_ []byte) {})
+
+	return verbs
+}
+
+/*
+verbArgName returns the name of argument the verb (w/o braces) refers to:
+"name" for "name", "func:name", "name:number" and "name|filter1|filter2".
+*/
+func verbArgName(verb string) string {
+	if idx := strings.IndexByte(verb, '|'); idx != -1 {
+		verb = verb[:idx]
+	} else if idx = strings.IndexByte(verb, ':'); idx != -1 && strings.TrimSpace(verb[idx+1:]) == _VERB_SPEC_NUMBER {
+		verb = verb[:idx]
+	} else if idx != -1 {
+		verb = verb[idx+1:]
+	}
+	return strings.TrimSpace(verb)
 }
 
 /*
 cbFoundText is a callback for ekastr.Interpolate() function,
 that is called when a just text part found (not an interpolation verb).
 
-Just writes it to the strings.Builder.
+Just writes it to the result.
 */
 func (ir *interpolator) cbFoundText(p []byte) {
-	_, _ = ir.builder.Write(p) // always returns not-nil error
+	ir.buf = append(ir.buf, p...)
 }
 
 /*
@@ -61,24 +390,203 @@ or original string if either there was no arguments or its a nil.
 Ignores unused arguments.
 Verbs that doesn't have associated argument remains as is.
 
-Verbs must be in the format: "{{<name>}}",
-<name> is key from Args.
+"{{{{" is an escaped "{{" and is written as a literal "{{" (e.g: "{{{{name}}"
+is written as "{{name}}"), see interpolateEscaped().
+
+Verbs must be in the format: "{{<name>}}", "{{<func>:<name>}}"
+or "{{<name>|<func1>|<func2>}}" (filters pipeline),
+spaces around <name> and <func> are ignored (e.g: "{{ name }}"),
+<name> is key from Args (or an index of positional argument, e.g: "{{0}}"),
+<func> is a name of registered InterpFunc.
+"{{<name>:number}}" formats numeric argument by the Locale's language rules.
+<name> might be dotted path to the nested map's value or struct's field
+(e.g: "{{user.name}}").
 */
 func (ir *interpolator) interpolate() string {
-	ekastr.Interpolateb(ir.rem, ir.cbFoundVerb, ir.cbFoundText)
-	return ir.builder.String()
+	ir.buf = make([]byte, 0, len(ir.rem) + 128)
+	ir.startIssues()
+	ir.interpolateTokens()
+	ir.finishIssues()
+	// buf is never changed after, because a new one is allocated for each call.
+	return ekastr.B2S(ir.buf)
+}
+
+/*
+interpolateAppend is the same as interpolate() but appends the interpolated phrase
+to dst and returns the extended buffer, w/o allocating its own one.
+*/
+func (ir *interpolator) interpolateAppend(dst []byte) []byte {
+	ir.buf = dst
+	ir.startIssues()
+	ir.interpolateTokens()
+	ir.finishIssues()
+	dst, ir.buf = ir.buf, nil
+	return dst
+}
+
+/*
+interpolateTokens writes the interpolated phrase to the result using its tokens
+(see compilePhrase()), if the phrase is compiled (see Config.CompilePhrases),
+or scanning the phrase for verbs otherwise.
+*/
+func (ir *interpolator) interpolateTokens() {
+
+	if ir.tokens == nil {
+		interpolateEscaped(ir.rem, ir.cbFoundVerb, ir.cbFoundText)
+		return
+	}
+
+	for i, n := 0, len(ir.tokens); i < n; i++ {
+		if ir.tokens[i].isVerb {
+			ir.writeVerb(ekastr.S2B(ir.tokens[i].text), ir.tokens[i].verb)
+		} else {
+			ir.writeString(ir.tokens[i].text)
+		}
+	}
+}
+
+/*
+interpolateEscaped is the same as ekastr.Interpolateb() but treats each "{{{{"
+(_VERB_ESCAPED_OPEN) as an escaped "{{", that is passed to cbText as is
+and is never treated as a beginning of the interpolation verb.
+The escapes are looked up before verbs, so the verb's name can't contain "{{{{",
+and the verb that is started by the escape is a just text (e.g: "{{{{name}}}}"
+is "{{name}}}}"). Only the opening braces need to be escaped,
+the closing ones w/o opening are a just text.
+*/
+func interpolateEscaped(p []byte, cbVerb, cbText func([]byte)) {
+
+	for idx := bytes.Index(p, escapedOpen); idx != -1; idx = bytes.Index(p, escapedOpen) {
+		if idx > 0 {
+			ekastr.Interpolateb(p[:idx], cbVerb, cbText)
+		}
+		cbText(escapedOpen[:2])
+		p = p[idx+len(escapedOpen):]
+	}
+
+	if len(p) > 0 {
+		ekastr.Interpolateb(p, cbVerb, cbText)
+	}
+}
+
+/*
+hasEscapedVerb reports whether phrase contains an escaped "{{" (see interpolateEscaped()),
+meaning it must be interpolated even if there is no arguments to collapse escapes.
+*/
+func hasEscapedVerb(phrase string) bool {
+	return strings.Contains(phrase, _VERB_ESCAPED_OPEN)
+}
+
+/*
+startIssues starts collecting the diagnostics of the interpolation,
+if Config.DebugInterpolation is enabled. Otherwise it's no-op,
+and the diagnostics are not collected at all (see reportMissing(), arg()).
+*/
+func (ir *interpolator) startIssues() {
+	ir.issues = nil
+	if atomic.LoadUint32(&ir.loc.owner.config.DebugInterpolation) == 1 {
+		ir.issues = &interpolationIssues{
+			used: make(map[string]struct{}),
+		}
+	}
+}
+
+/*
+reportMissing saves the name of argument the verb refers to as missing one,
+if the diagnostics are collected (see startIssues()).
+*/
+func (ir *interpolator) reportMissing(verb string) {
+	if ir.issues != nil {
+		ir.issues.missing = append(ir.issues.missing, verbArgName(verb))
+	}
+}
+
+/*
+finishIssues calls OnInterpolationIssue callback (see Client.SetOnInterpolationIssue())
+with missing and unused arguments, if the diagnostics are collected
+(see startIssues()) and there are any. The arguments of Locale's view
+(see Locale.WithArgs()) and the reserved ones that select the phrase's form
+(_PLURAL_COUNT_ARG, _GENDER_ARG) are never reported as unused.
+*/
+func (ir *interpolator) finishIssues() {
+
+	if ir.issues == nil {
+		return
+	}
+
+	var unused []string
+	for name := range ir.args {
+		if _, isUsed := ir.issues.used[name]; isUsed {
+			continue
+		}
+		if name == _PLURAL_COUNT_ARG || name == _GENDER_ARG {
+			continue
+		}
+		if _, isDefault := ir.loc.defaultArgs[name]; !isDefault {
+			unused = append(unused, name)
+		}
+	}
+
+	if len(ir.issues.missing) != 0 || len(unused) != 0 {
+		sort.Strings(unused)
+		ir.loc.owner.callOnInterpolationIssue(ir.key, ir.issues.missing, unused)
+	}
+
+	ir.issues = nil
+}
+
+/*
+writeString appends s to the result.
+*/
+func (ir *interpolator) writeString(s string) {
+	ir.buf = append(ir.buf, s...)
 }
 
 /*
 newInterpolator is a interpolator constructor.
-Transforms phrase to []byte w/ no-copy and grows builder's internal buffer
-to the phrase's len + 128 bytes.
+loc is a Locale the phrase is taken from, it must be valid.
+key is a translation key of the phrase, it's used for diagnostics only.
+Transforms phrase to []byte w/ no-copy. The buffer for the result
+is allocated by interpolate() call.
 */
-func newInterpolator(phrase string, args Args) *interpolator {
-	i := &interpolator{
+func newInterpolator(loc *Locale, key, phrase string, args Args) *interpolator {
+	return &interpolator{
+		loc:  loc,
+		key:  key,
 		args: args,
 		rem:  ekastr.S2B(phrase),
 	}
-	i.builder.Grow(len(i.rem) + 128)
-	return i
+}
+
+/*
+withPositional sets positional arguments (see Locale.Trf()) the verbs
+of digits only names (e.g: "{{0}}", "{{1}}") are replaced by.
+*/
+func (ir *interpolator) withPositional(args []interface{}) *interpolator {
+	ir.posArgs = args
+	return ir
+}
+
+/*
+withTokens sets the tokens of the phrase (see compilePhrase()), that are returned
+along with it by Locale.lookup(), so the phrase is not scanned for verbs again.
+nil tokens mean the phrase is not compiled.
+*/
+func (ir *interpolator) withTokens(tokens []phraseToken) *interpolator {
+	ir.tokens = tokens
+	return ir
+}
+
+/*
+reset prepares the current interpolator to interpolate another phrase
+using another args, so one interpolator could be reused for a batch of phrases.
+Strings that are returned by interpolate() before are not affected.
+*/
+func (ir *interpolator) reset(key, phrase string, args Args) *interpolator {
+	ir.key = key
+	ir.args = args
+	ir.rem = ekastr.S2B(phrase)
+	ir.tokens = nil
+	ir.buf = nil
+	return ir
 }
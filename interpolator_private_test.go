@@ -0,0 +1,238 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qioalice/ekago/v2/ekastr"
+)
+
+func TestInterpolatorIssues(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"greeting": "Hi, {{name}}",
+		"items":    map[string]interface{}{"one": "One item", "other": "Many items"},
+		"invited":  map[string]interface{}{"female": "She invited you", "other": "They invited you"},
+		"filtered": "Hi, {{name|shout}}",
+		"func":     "Hi, {{shout:name}}",
+		"number":   "Total: {{total:number}}",
+		"upper":    "Hi, {{name|upper}}",
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	c.RegisterInterpFunc("upper", func(arg interface{}) string {
+		return strings.ToUpper(ekastr.ToString(arg))
+	})
+	c.SetDebugInterpolation(true)
+
+	var (
+		missing, unused, unknownFilters []string
+	)
+
+	c.SetOnInterpolationIssue(func(_ string, missingArgs, unusedArgs []string) {
+		missing, unused = missingArgs, unusedArgs
+	})
+	c.SetOnUnknownFilter(func(_, _, filter string) {
+		unknownFilters = append(unknownFilters, filter)
+	})
+
+	tests := []struct {
+		name           string
+		key            string
+		args           Args
+		missing        []string
+		unused         []string
+		unknownFilters []string
+	}{
+		{"no issues", "greeting", Args{"name": "Bob"}, nil, nil, nil},
+		{"missing", "greeting", Args{"count": 1}, []string{"name"}, nil, nil},
+		{"unused", "greeting", Args{"name": "Bob", "age": 30}, nil, []string{"age"}, nil},
+		{"reserved count", "items", Args{"count": 1}, nil, nil, nil},
+		{"reserved gender", "invited", Args{"gender": "female"}, nil, nil, nil},
+		{"unknown filter", "filtered", Args{"name": "Bob"}, nil, nil, []string{"shout"}},
+		{"unknown filter, missing arg", "filtered", Args{"count": 1}, []string{"name"}, nil, []string{"shout"}},
+		{"unknown func", "func", Args{"name": "Bob"}, nil, nil, []string{"shout"}},
+		{"missing number", "number", Args{"count": 1}, []string{"total"}, nil, nil},
+		{"known filter", "upper", Args{"name": "Bob", "age": 30}, nil, []string{"age"}, nil},
+	}
+
+	for _, test := range tests {
+		missing, unused, unknownFilters = nil, nil, nil
+
+		c.LC("en_US").Tr(test.key, test.args)
+
+		if !reflect.DeepEqual(missing, test.missing) {
+			t.Errorf("%s: missing %v, expected %v", test.name, missing, test.missing)
+		}
+		if !reflect.DeepEqual(unused, test.unused) {
+			t.Errorf("%s: unused %v, expected %v", test.name, unused, test.unused)
+		}
+		if !reflect.DeepEqual(unknownFilters, test.unknownFilters) {
+			t.Errorf("%s: unknown filters %v, expected %v", test.name, unknownFilters, test.unknownFilters)
+		}
+	}
+}
+
+func TestInterpolatorVerbWhitespace(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"none":    "Hi, {{name}}",
+		"both":    "Hi, {{ name }}",
+		"left":    "Hi, {{  name}}",
+		"right":   "Hi, {{name   }}",
+		"tabs":    "Hi, {{\tname\t}}",
+		"number":  "Total: {{ total : number }}",
+		"filter":  "Hi, {{ name | upper }}",
+		"path":    "Hi, {{ user.name }}",
+		"missing": "Hi, {{ nick }}",
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	c.RegisterInterpFunc("upper", func(arg interface{}) string {
+		return strings.ToUpper(ekastr.ToString(arg))
+	})
+
+	args := Args{
+		"name":  "Bob",
+		"total": 1000,
+		"user":  map[string]interface{}{"name": "Bob"},
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{"no spaces", "none", "Hi, Bob"},
+		{"spaces around", "both", "Hi, Bob"},
+		{"left spaces", "left", "Hi, Bob"},
+		{"right spaces", "right", "Hi, Bob"},
+		{"tabs", "tabs", "Hi, Bob"},
+		{"number spec", "number", "Total: 1,000"},
+		{"filter", "filter", "Hi, BOB"},
+		{"dotted path", "path", "Hi, Bob"},
+		{"missing is kept as is", "missing", "Hi, {{ nick }}"},
+	}
+
+	for _, test := range tests {
+		if translated := c.LC("en_US").Tr(test.key, args); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+	}
+}
+
+func TestInterpolatorNilArgs(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"greeting": "Hi, {{name}}!",
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	var (
+		nilPtr   *int
+		nilMap   map[string]string
+		nilSlice []string
+		nilErr   error
+		nilFunc  func()
+		nilChan  chan int
+	)
+
+	tests := []struct {
+		name     string
+		arg      interface{}
+		expected string
+		rendered string
+	}{
+		{"untyped nil", nil, "Hi, !", "Hi, <name>!"},
+		{"nil pointer", nilPtr, "Hi, !", "Hi, <name>!"},
+		{"nil map", nilMap, "Hi, !", "Hi, <name>!"},
+		{"nil slice", nilSlice, "Hi, !", "Hi, <name>!"},
+		{"nil interface", nilErr, "Hi, !", "Hi, <name>!"},
+		{"nil func", nilFunc, "Hi, !", "Hi, <name>!"},
+		{"nil chan", nilChan, "Hi, !", "Hi, <name>!"},
+		{"zero", 0, "Hi, 0!", "Hi, 0!"},
+		{"empty string", "", "Hi, !", "Hi, !"},
+		{"false", false, "Hi, false!", "Hi, false!"},
+	}
+
+	for _, test := range tests {
+		c.SetNilArgRenderer(nil)
+		if translated := c.Tr("en_US", "greeting", Args{"name": test.arg}); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+
+		c.SetNilArgRenderer(func(verb string) string { return "<" + verb + ">" })
+		if translated := c.Tr("en_US", "greeting", Args{"name": test.arg}); translated != test.rendered {
+			t.Errorf("%s (rendered): %q, expected %q", test.name, translated, test.rendered)
+		}
+	}
+}
+
+func TestInterpolatorLiteralBraces(t *testing.T) {
+
+	phrases := map[string]interface{}{
+		"open":          "{{{{",
+		"open twice":    "{{{{{{{{",
+		"close":         "}}",
+		"close twice":   "}}}}",
+		"empty verb":    "{{{{}}",
+		"pair":          "{{{{}}{{{{}}",
+		"nested":        "{{{{{{{{}}}}",
+		"escaped verb":  "{{{{name}}",
+		"escaped close": "{{{{name}}}}",
+		"code":          "func() {{{{ return }} }",
+	}
+
+	var c Client
+	if err := c.AddLocale("en_US", phrases); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	var compiled Client
+	compiled.SetCompilePhrases(true)
+	if err := compiled.AddLocale("en_US", phrases); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"open", "{{"},
+		{"open twice", "{{{{"},
+		{"close", "}}"},
+		{"close twice", "}}}}"},
+		{"empty verb", "{{}}"},
+		{"pair", "{{}}{{}}"},
+		{"nested", "{{{{}}}}"},
+		{"escaped verb", "{{name}}"},
+		{"escaped close", "{{name}}}}"},
+		{"code", "func() {{ return }} }"},
+	}
+
+	for _, test := range tests {
+		for _, args := range []Args{nil, {"name": "Bob"}} {
+			if translated := c.Tr("en_US", test.key, args); translated != test.expected {
+				t.Errorf("%s (args: %v): %q, expected %q", test.key, args, translated, test.expected)
+			}
+			if translated := compiled.Tr("en_US", test.key, args); translated != test.expected {
+				t.Errorf("%s (compiled, args: %v): %q, expected %q", test.key, args, translated, test.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"sort"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+decodeKeyOrder decodes the content of passed type once again, but only to extract
+the declaration order of keys, which is lost after decoding to the map.
+
+Returns a map of node's full translation key (joined by DEFAULT_DELIMITER,
+empty for root) to the names of its keys in declaration order.
+Returns nil if content can not be decoded (it never happens
+if it has been decoded to the map successfully before).
+*/
+func decodeKeyOrder(typ SourceItemType, content []byte) map[string][]string {
+
+	keyOrder := make(map[string][]string)
+
+	switch typ {
+
+	case SOURCE_ITEM_TYPE_FILE_YAML, SOURCE_ITEM_TYPE_CONTENT_YAML,
+		SOURCE_ITEM_TYPE_FILE_JSON, SOURCE_ITEM_TYPE_CONTENT_JSON:
+
+		// JSON is a subset of YAML, so YAML decoder keeps its keys order too.
+		var doc yaml.Node
+		if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+			return nil
+		}
+		decodeKeyOrderYAML(keyOrder, "", doc.Content[0])
+
+	case SOURCE_ITEM_TYPE_FILE_TOML, SOURCE_ITEM_TYPE_CONTENT_TOML:
+		tree, err := toml.LoadBytes(content)
+		if err != nil {
+			return nil
+		}
+		decodeKeyOrderTOML(keyOrder, "", tree)
+
+	default:
+		return nil
+	}
+
+	return keyOrder
+}
+
+/*
+decodeKeyOrderYAML saves the names of passed YAML mapping node's keys
+in declaration order to the keyOrder by the passed key, doing the same
+for each nested mapping node recursively.
+*/
+func decodeKeyOrderYAML(keyOrder map[string][]string, key string, node *yaml.Node) {
+
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	names := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		names = append(names, name)
+		decodeKeyOrderYAML(keyOrder, joinKey(key, name), node.Content[i+1])
+	}
+
+	keyOrder[key] = names
+}
+
+/*
+decodeKeyOrderTOML saves the names of passed TOML tree's keys
+sorted by their position in the document to the keyOrder by the passed key,
+doing the same for each nested tree (table) recursively.
+*/
+func decodeKeyOrderTOML(keyOrder map[string][]string, key string, tree *toml.Tree) {
+
+	names := tree.Keys()
+
+	sort.SliceStable(names, func(i, j int) bool {
+		pi := tree.GetPositionPath([]string{names[i]})
+		pj := tree.GetPositionPath([]string{names[j]})
+		return pi.Line < pj.Line || pi.Line == pj.Line && pi.Col < pj.Col
+	})
+
+	for _, name := range names {
+		if subTree, ok := tree.GetPath([]string{name}).(*toml.Tree); ok {
+			decodeKeyOrderTOML(keyOrder, joinKey(key, name), subTree)
+		}
+	}
+
+	keyOrder[key] = names
+}
+
+/*
+joinKey joins the node's full translation key and the name of its key
+by DEFAULT_DELIMITER. Name is returned as is, if node's key is empty (root).
+*/
+func joinKey(key, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + string(DEFAULT_DELIMITER) + name
+}
@@ -0,0 +1,157 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+	"github.com/qioalice/ekago/v2/ekastr"
+)
+
+type (
+	/*
+	listFormat is a minimal subset of CLDR list formatting rules of Locale,
+	that is used to join the items of slice interpolation argument,
+	like "Alice, Bob, and Carol".
+
+	separator is placed between items, conjunction is placed before the last one.
+	They might be declared by metadata's "list_separator" and "list_conjunction"
+	fields, otherwise the defaults of Locale's language are used
+	(see listFormatDefault()).
+	*/
+	listFormat struct {
+		separator   string
+		conjunction string
+	}
+)
+
+/*
+listFormatDefault returns the listFormat for the language of passed locale name.
+If the language is unknown, English rules are used.
+*/
+func listFormatDefault(localeName string) listFormat {
+
+	conjunction := ", and "
+	switch localeLanguage(localeName) {
+	case "ru":
+		conjunction = " и "
+	case "uk", "be":
+		conjunction = " і "
+	case "de":
+		conjunction = " und "
+	case "fr":
+		conjunction = " et "
+	case "es":
+		conjunction = " y "
+	case "it", "pt":
+		conjunction = " e "
+	case "nl":
+		conjunction = " en "
+	case "pl":
+		conjunction = " i "
+	case "cs", "sk":
+		conjunction = " a "
+	case "sv":
+		conjunction = " och "
+	case "nb", "no", "da":
+		conjunction = " og "
+	case "fi":
+		conjunction = " ja "
+	case "tr":
+		conjunction = " ve "
+	}
+
+	return listFormat{
+		separator:   ", ",
+		conjunction: conjunction,
+	}
+}
+
+/*
+merge fills the empty rules of the current listFormat by the rules of other.
+Returns an error if both of them have the same rule, but it's different.
+*/
+func (lf *listFormat) merge(other listFormat) *ekaerr.Error {
+
+	for _, rule := range []struct {
+		name       string
+		dest       *string
+		otherValue string
+	}{
+		{"list_separator", &lf.separator, other.separator},
+		{"list_conjunction", &lf.conjunction, other.conjunction},
+	} {
+		switch {
+		case rule.otherValue == "":
+		case *rule.dest == "":
+			*rule.dest = rule.otherValue
+		case *rule.dest != rule.otherValue:
+			return ekaerr.IllegalFormat.
+				New("Locale's list formatting rule is ambiguous. Sources declare different ones.").
+				AddFields(
+					"privet_metadata_rule",   rule.name,
+					"privet_metadata_rule_1", *rule.dest,
+					"privet_metadata_rule_2", rule.otherValue).
+				Throw()
+		}
+	}
+
+	return nil
+}
+
+/*
+formatList returns a string representation of arg joined by the list formatting
+rules of loc (see listFormat), if arg is a slice or an array (except []byte).
+Each item is converted to the string the same way as a regular argument.
+The 2nd returned value is false if arg is not a slice or an array.
+
+The conjunction that starts with the separator's punctuation
+(like English's ", and ") is used w/o it, if there are only two items
+("Alice and Bob").
+*/
+func formatList(loc *Locale, arg interface{}) (string, bool) {
+
+	v := reflect.ValueOf(arg)
+	if kind := v.Kind(); kind != reflect.Slice && kind != reflect.Array ||
+		v.Type().Elem().Kind() == reflect.Uint8 {
+
+		return "", false
+	}
+
+	rules := loc.listFormat
+	defaultRules := listFormatDefault(loc.name)
+	if rules.separator == "" {
+		rules.separator = defaultRules.separator
+	}
+	if rules.conjunction == "" {
+		rules.conjunction = defaultRules.conjunction
+	}
+
+	n := v.Len()
+	items := make([]string, n)
+	for i := 0; i < n; i++ {
+		items[i] = ekastr.ToString(v.Index(i).Interface())
+	}
+
+	switch n {
+	case 0:
+		return "", true
+	case 1:
+		return items[0], true
+	case 2:
+		conjunction := rules.conjunction
+		if punctuation := strings.TrimSpace(rules.separator); punctuation != "" {
+			if trimmed := strings.TrimPrefix(conjunction, punctuation); trimmed != conjunction {
+				conjunction = trimmed
+			}
+		}
+		return items[0] + conjunction + items[1], true
+	default:
+		return strings.Join(items[:n-1], rules.separator) + rules.conjunction + items[n-1], true
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"github.com/qioalice/ekago/v2/ekaerr"
+)
+
+type (
+	/*
+	LoadReport is a summary of one Client.Load() call.
+	It contains the problems that were found during loading,
+	but haven't lead to the Load() error because of Client's config.
+
+	Use Client.LastLoadReport() to get a LoadReport of the last Load() call.
+	*/
+	LoadReport struct {
+		PhrasesLoaded  uint64 // including the ones that overwrite already loaded
+		Conflicts      []LoadConflict
+		SkippedSources []LoadSkippedSource
+		Warnings       []LoadWarning
+	}
+
+	/*
+	LoadConflict describes one translation key that was found in two or more
+	sources of the same locale, when overwriting is prohibited
+	(Config.OverwriteExistingKey is false).
+	Old (first loaded) language phrase is kept.
+	*/
+	LoadConflict struct {
+		LocaleName string
+		Key        string   // full translation key
+		OldValue   string   // kept language phrase
+		NewValue   string   // rejected language phrase
+		OldSources []string // paths of sources that construct the node old value is from
+		NewSource  string   // path of source new value is from
+	}
+
+	/*
+	LoadWarning describes something suspicious in the loaded source,
+	that is not an error, but most likely is a mistake.
+	E.g: a key that contains the key delimiter, so it's unreachable by Locale.Tr().
+	*/
+	LoadWarning struct {
+		LocaleName string
+		Key        string // full translation key (or node's key) warning is about
+		Source     string // path of source
+		Message    string
+	}
+
+	/*
+	LoadSkippedSource describes one source that has been skipped because of error,
+	when Config.SkipInvalidSources is true.
+	None of its language phrases are loaded.
+	*/
+	LoadSkippedSource struct {
+		Path  string
+		Error *ekaerr.Error
+	}
+)
@@ -6,7 +6,12 @@
 package privet
 
 import (
-	"strings"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
 )
 
 type (
@@ -27,8 +32,14 @@ type (
 	Locale struct {
 		owner        *Client
 		root         *localeNode
-		name         string      // in format xx_YY
+		name         string      // canonical BCP 47 like tag: xx_YY, xx_Scrp_YY or xx
+		inherits     string      // parent locale name, missing keys are looked up there
+		fallbacks    []string    // fallback locale names from metadata, may be empty
+		listFormat   listFormat  // from metadata, language's defaults are used for empty fields
+		requiredArgs map[string][]string // required args by translation key from metadata
 		phrasesCount uint64      // not only root localeNode but all nested also
+		base         *Locale     // original Locale if it's a WithArgs() view, nil otherwise
+		defaultArgs  Args        // args of WithArgs() view, merged with each Tr() args
 	}
 )
 
@@ -49,46 +60,875 @@ All of special returned strings has the same format:
  - _SPTR_LOCALE_IS_NIL:                Current Locale object is nil,
  - _SPTR_TRANSLATION_KEY_IS_EMPTY:     Translation key is empty,
  - _SPTR_TRANSLATION_KEY_IS_INCORRECT: Translation key is invalid (incorrect separator),
- - _SPTR_TRANSLATION_NOT_FOUND:        Translation not found.
+ - _SPTR_TRANSLATION_NOT_FOUND:        Translation not found,
+ - _SPTR_TRANSLATION_KEY_IS_NODE:      Translation key points to the node, not a phrase
+                                       (e.g: "Menu/File" for "Menu/File/Open"),
+                                       only if Config.StrictKeyPath is enabled.
+
+If the current Locale inherits another one (metadata's "inherits" field),
+the translation key is looked up in the parent Locale, if it's not found
+in the current one. And so on, until the root of inheritance.
+
+If Config.MissingKeyFallbackToLeaf is set to true (false by default),
+the last segment of translation key is returned instead of
+_SPTR_TRANSLATION_NOT_FOUND special string (e.g: "Open" for "Menu/File/Open").
+
+If args has an integer "count" argument (of any integer or float type
+or a string representation of integer), the key may point to the node
+of plural categories' phrases, the same way as for TrCount(), like:
+
+        Cart:
+          Items:
+            one: "{{count}} item"
+            other: "{{count}} items"
+
+and then:
+
+        loc.Tr("Cart/Items", privet.Args{"count": 5}) // "5 items"
+
+The category is selected by the plural rules of the current Locale's language
+(see PluralCategory()), falling back to the "other" category's phrase.
+Unlike TrCount(), the "count" argument is interpolated as is.
+
+If args has a "gender" argument (a string or fmt.Stringer, like "male",
+"female" or "neutral"), the key may point to the node of genders' phrases:
+
+        Invited:
+          male: "{{name}} пригласил вас"
+          female: "{{name}} пригласила вас"
+          neutral: "{{name}} пригласило вас"
+
+and then:
+
+        loc.Tr("Invited", privet.Args{"name": "Анна", "gender": "female"})
+
+If there is no phrase for the requested gender, the "neutral" one is used,
+or the first available of "male", "female". The gender node's phrases
+might be plural nodes too, if "count" argument is passed as well.
+
+If the language phrase is not found, the fallback locales are consulted
+in order, see Client.SetFallback() and Client.SetFallbackToDefault().
+The fallback locales might be declared by metadata's "fallback" field too.
 */
 func (l *Locale) Tr(key string, args Args) string {
 
+	if !l.isValid() {
+		return sptr(_SPTR_LOCALE_IS_NIL, key)
+	}
+
+	args = l.mergeArgs(args)
+
+	switch translatedPhrase, tokens, class := l.lookupCounted(key, args); {
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return l.trMissing(key)
+
+	case class != "":
+		return sptr(class, key)
+
+	case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+		return newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolate()
+
+	default:
+		return translatedPhrase
+	}
+}
+
+/*
+Trf is the same as Tr() but takes positional arguments instead of Args.
+The verbs which names consist of digits only are replaced by the arguments
+with the same index, so the same argument might be used twice
+or in the different order than they are passed:
+
+        Greeting: "Hi, {{0}}! {{1}} messages for you, {{0}}."
+
+and then:
+
+        loc.Trf("Greeting", "Alice", 3) // "Hi, Alice! 3 messages for you, Alice."
+
+Verbs of out of range indexes are kept untouched.
+Named verbs are replaced by the arguments of the Locale's view (see WithArgs()),
+filters and InterpFunc might be used with positional arguments as well
+(e.g: "{{0|upper}}", "{{money:1}}").
+*/
+func (l *Locale) Trf(key string, args ...interface{}) string {
+
+	if !l.isValid() {
+		return sptr(_SPTR_LOCALE_IS_NIL, key)
+	}
+
+	namedArgs := l.mergeArgs(nil)
+
+	switch translatedPhrase, tokens, class := l.lookupCounted(key, namedArgs); {
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return l.trMissing(key)
+
+	case class != "":
+		return sptr(class, key)
+
+	case len(args) != 0 || len(namedArgs) != 0 || hasEscapedVerb(translatedPhrase):
+		return newInterpolator(l, key, translatedPhrase, namedArgs).withTokens(tokens).withPositional(args).interpolate()
+
+	default:
+		return translatedPhrase
+	}
+}
+
+/*
+TrE is the same as Tr() but returns an error instead of special strings,
+for the server code that wants to log and handle misses:
+
+ - ekaerr.IllegalState:    Locale is nil or not valid,
+ - ekaerr.IllegalArgument: Translation key is empty, malformed
+                           or points to the node, not a phrase,
+ - ekaerr.NotFound:        Translation not found.
+
+The language phrase is looked up exactly as Tr() does (including inherited
+and fallback locales), but neither OnMissing callback is called
+nor Config.MissingKeyFallbackToLeaf is used when it's not found.
+
+Nil safe. If this method is called on nil object, an error is returned.
+*/
+func (l *Locale) TrE(key string, args Args) (string, *ekaerr.Error) {
+	const s = "Failed to translate a key. "
+
+	if !l.isValid() {
+		return "", ekaerr.IllegalState.
+			New(s + "Locale is nil or not valid.").
+			AddFields("privet_key", key).
+			Throw()
+	}
+
+	args = l.mergeArgs(args)
+
+	switch translatedPhrase, tokens, class := l.lookupCounted(key, args); class {
+
+	case "":
+		if len(args) != 0 || hasEscapedVerb(translatedPhrase) {
+			translatedPhrase = newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolate()
+		}
+		return translatedPhrase, nil
+
+	case _SPTR_TRANSLATION_NOT_FOUND:
+		return "", ekaerr.NotFound.
+			New(s + "Translation not found.").
+			AddFields(
+				"privet_key",         key,
+				"privet_locale_name", l.name).
+			Throw()
+
+	case _SPTR_TRANSLATION_KEY_IS_NODE:
+		return "", ekaerr.IllegalArgument.
+			New(s + "Translation key points to the node, not a phrase.").
+			AddFields(
+				"privet_key",         key,
+				"privet_locale_name", l.name).
+			Throw()
+
+	default:
+		return "", ekaerr.IllegalArgument.
+			New(s + "Translation key is empty or malformed.").
+			AddFields(
+				"privet_key",         key,
+				"privet_locale_name", l.name).
+			Throw()
+	}
+}
+
+/*
+TrCtx is the same as Tr() but checks whether ctx is done before
+and after the phrase is interpolated, and returns ctx.Err() if so.
+It allows to bail out of the big batch renders (thousands of keys)
+as soon as they are cancelled.
+
+The returned error is ctx.Err() as is (e.g: context.Canceled),
+other errors are reported by the special strings, like Tr() does.
+Tr() and other methods remain context-free.
+
+Nil safe. Returns the same special strings as Tr() does.
+*/
+func (l *Locale) TrCtx(ctx context.Context, key string, args Args) (string, error) {
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	translated := l.Tr(key, args)
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return translated, nil
+}
+
+/*
+TrAppend is the same as Tr() but appends the interpolated language phrase
+(or the special string, see Tr()) to dst and returns the extended buffer.
+The phrase is not allocated as a separate string, so there is no allocations
+for the result, if dst has enough capacity. It's designed for template rendering,
+when the caller owns the buffer.
+
+Nil safe. Appends the special string if this method is called on nil object.
+*/
+func (l *Locale) TrAppend(dst []byte, key string, args Args) []byte {
+
+	if !l.isValid() {
+		return append(dst, sptr(_SPTR_LOCALE_IS_NIL, key)...)
+	}
+
+	args = l.mergeArgs(args)
+
+	switch translatedPhrase, tokens, class := l.lookupCounted(key, args); {
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return append(dst, l.trMissing(key)...)
+
+	case class != "":
+		return append(dst, sptr(class, key)...)
+
+	case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+		return newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolateAppend(dst)
+
+	default:
+		return append(dst, translatedPhrase...)
+	}
+}
+
+/*
+TrSafe is the same as Tr() but never returns a special string
+(like "i18nErr: TranslationNotFound. Key: <key>"), returning a placeholder instead.
+The placeholder is an empty string by default and may be changed using
+Client.SetSafePlaceholder().
+
+It's designed for UI code, where the diagnostic strings must not be shown to users.
+The OnMissing callback is still called for the missing keys (see Client.SetOnMissing()),
+so the missing translations are still observable.
+
+Nil safe. Returns an empty string if this method is called on nil object.
+*/
+func (l *Locale) TrSafe(key string, args Args) string {
+
+	if !l.isValid() {
+		return ""
+	}
+
+	args = l.mergeArgs(args)
+
+	switch translatedPhrase, tokens, class := l.lookupCounted(key, args); {
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		// trMissing() may return the last key's segment, that is not a garbage.
+		if translated := l.trMissing(key); translated != sptr(class, key) {
+			return translated
+		}
+		return l.owner.getSafePlaceholder()
+
+	case class != "":
+		return l.owner.getSafePlaceholder()
+
+	case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+		return newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolate()
+
+	default:
+		return translatedPhrase
+	}
+}
+
+/*
+TrDefault is the same as Tr() but if there is no language phrase
+for the requested key in the current Locale (and the Locales it inherits,
+and its fallback locales), the key is looked up in the Client's default Locale
+(see MarkAsDefault()).
+
+It's the common "my language, otherwise the site's default" pattern,
+that does not require to configure the inheritance for each Locale.
+
+Nil safe. Returns the same special strings as Tr() does.
+*/
+func (l *Locale) TrDefault(key string, args Args) string {
+
+	if !l.isValid() {
+		return sptr(_SPTR_LOCALE_IS_NIL, key)
+	}
+
+	args = l.mergeArgs(args)
+	translatedPhrase, tokens, class := l.lookupCounted(key, args)
+
+	if class == _SPTR_TRANSLATION_NOT_FOUND {
+		defaultLocale := l.owner.getDefaultLocale()
+		if defaultLocale != nil && defaultLocale.root != l.root {
+			translatedPhrase, tokens, class = defaultLocale.lookupCounted(key, args)
+		}
+	}
+
 	switch {
-	case !l.isValid():
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return l.trMissing(key)
+
+	case class != "":
+		return sptr(class, key)
+
+	case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+		return newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolate()
+
+	default:
+		return translatedPhrase
+	}
+}
+
+/*
+TrMap translates a batch of translation keys, each one with its own args,
+and returns a map of translation key to the interpolated language phrase.
+It's the same as calling Tr() for each key.
+
+It's useful for the "render these N distinct messages" case,
+like messages of form validation:
+
+        loc.TrMap(map[string]privet.Args{
+            "Form/Errors/TooShort": {"field": "name", "min": 3},
+            "Form/Errors/Required": {"field": "email"},
+        })
+
+Missing keys are mapped to the same special strings as Tr() returns.
+
+Nil safe. If this method is called on nil object,
+each key is mapped to the special string.
+*/
+func (l *Locale) TrMap(requests map[string]Args) map[string]string {
+
+	translated := make(map[string]string, len(requests))
+
+	if !l.isValid() {
+		for key := range requests {
+			translated[key] = sptr(_SPTR_LOCALE_IS_NIL, key)
+		}
+		return translated
+	}
+
+	var ir *interpolator
+
+	for key, args := range requests {
+		args = l.mergeArgs(args)
+
+		switch translatedPhrase, tokens, class := l.lookupCounted(key, args); {
+
+		case class == _SPTR_TRANSLATION_NOT_FOUND:
+			translated[key] = l.trMissing(key)
+
+		case class != "":
+			translated[key] = sptr(class, key)
+
+		case (len(args) != 0 || hasEscapedVerb(translatedPhrase)) && ir == nil:
+			ir = newInterpolator(l, key, translatedPhrase, args).withTokens(tokens)
+			translated[key] = ir.interpolate()
+
+		case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+			translated[key] = ir.reset(key, translatedPhrase, args).withTokens(tokens).interpolate()
+
+		default:
+			translated[key] = translatedPhrase
+		}
+	}
+
+	return translated
+}
+
+/*
+Trp is the same as Tr() but formats the language phrase using fmt.Sprintf()
+with passed args, instead of "{{name}}" interpolation.
+It's an opt-in for the legacy phrases with printf verbs ("%s", "%d"),
+e.g. while migrating old catalogs:
+
+        Cart:
+          Total: "You have %d items for %s"
+
+        loc.Trp("Cart/Total", 3, "$12") // "You have 3 items for $12"
+
+If the args don't match the verbs, the result contains fmt's error markers,
+like "%!d(MISSING)" or "%!(EXTRA string=...)". Phrase is returned as is
+if there is no args (so "%" does not need to be escaped).
+WithArgs() default args are not used.
+
+Nil safe. Returns the same special strings as Tr() does.
+*/
+func (l *Locale) Trp(key string, args ...interface{}) string {
+
+	if !l.isValid() {
 		return sptr(_SPTR_LOCALE_IS_NIL, key)
-	case key == "":
-		return sptr(_SPTR_TRANSLATION_KEY_IS_EMPTY, key)
 	}
 
-	var (
-		prefix      string
-		originalKey = key
-	)
+	switch translatedPhrase, _, class := l.lookupCounted(key, nil); {
 
-	for node := l.root; node != nil; {
-		if idx := strings.IndexByte(key, DEFAULT_DELIMITER); idx != -1 {
-			prefix, key = key[:idx], key[idx+1:]
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return l.trMissing(key)
 
-			if len(key) == 0 || len(prefix) == 0 {
-				return sptr(_SPTR_TRANSLATION_KEY_IS_INCORRECT, originalKey)
-			}
+	case class != "":
+		return sptr(class, key)
+
+	case len(args) != 0:
+		return fmt.Sprintf(translatedPhrase, args...)
+
+	default:
+		return translatedPhrase
+	}
+}
+
+/*
+TrCount is the same as Tr() but selects the plural form of the phrase for n
+and interpolates it using n as "count" argument, formatted with the grouping
+of thousands by the current Locale's language rules (e.g: "1,000" for "en_US").
+
+The key must point to the node that contains language phrases
+for CLDR plural categories ("zero", "one", "two", "few", "many", "other"), like:
+
+        Inbox:
+          Messages:
+            one: "You have {{count}} message"
+            other: "You have {{count}} messages"
+
+and then:
+
+        loc.TrCount("Inbox/Messages", 1000) // "You have 1,000 messages"
+
+The "other" category phrase is used if there is no phrase for the selected one.
+If the key points to the phrase itself, that phrase is used for any n.
+
+Nil safe. Returns the same special strings as Tr() does.
+*/
+func (l *Locale) TrCount(key string, n int) string {
+
+	if !l.isValid() {
+		return sptr(_SPTR_LOCALE_IS_NIL, key)
+	}
+
+	translatedPhrase, tokens, class := l.lookupFallback(func(loc *Locale) (string, []phraseToken, _SpecialTranslationClass) {
+		return loc.lookupPlural(key, int64(n))
+	})
+
+	switch {
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return l.trMissing(key)
+
+	case class != "":
+		return sptr(class, key)
+
+	default:
+		args := l.mergeArgs(Args{_PLURAL_COUNT_ARG: formatInteger(l.name, int64(n))})
+		return newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolate()
+	}
+}
+
+/*
+Has reports whether there is a language phrase for the requested key,
+looking it up exactly as Tr() w/o arguments does (including inherited
+and fallback locales), but w/o interpolation and special strings.
+It allows to distinguish a missing phrase from a really empty one.
+
+Nil safe. If this method is called on nil object, false is returned.
+*/
+func (l *Locale) Has(key string) bool {
+
+	if !l.isValid() {
+		return false
+	}
+
+	_, _, class := l.lookupCounted(key, nil)
+	return class == ""
+}
+
+/*
+Raw returns the language phrase the key points to as it's stored,
+with its interpolation verbs (e.g: "{{name}}") intact. The phrase is looked up
+exactly as Tr() w/o arguments does (including inherited and fallback locales),
+but it's neither interpolated nor replaced by a special string.
+The second returned value reports whether the phrase is found.
+
+It's useful to pass the template to a client-side interpolator
+or to debug why the interpolation misbehaves.
+
+Nil safe. If this method is called on nil object, "", false is returned.
+*/
+func (l *Locale) Raw(key string) (string, bool) {
+
+	if !l.isValid() {
+		return "", false
+	}
+
+	translatedPhrase, _, class := l.lookupCounted(key, nil)
+	if class != "" {
+		return "", false
+	}
+
+	return translatedPhrase, true
+}
+
+/*
+TrVariant is the same as Tr() but prefers the language phrase of passed variant
+(e.g: platform or A/B test group), that is placed under the "__<variant>__" sub key
+of the translation key, like:
+
+        Welcome:
+          Title:
+            __default__: "Welcome to our website"
+            __mobile__: "Welcome to our app"
+
+and then:
+
+        loc.TrVariant("Welcome/Title", "mobile", nil) // "Welcome to our app"
+        loc.TrVariant("Welcome/Title", "web", nil)    // "Welcome to our website"
+
+Falls back to the phrase the key points to, if there is no phrase of the variant
+(neither in the current Locale nor in the Locales it inherits) or variant is empty,
+and then to the "__default__" sub key's phrase, if the key points to the node.
+
+Nil safe. Returns the same special strings as Tr() does.
+*/
+func (l *Locale) TrVariant(key, variant string, args Args) string {
+
+	if !l.isValid() {
+		return sptr(_SPTR_LOCALE_IS_NIL, key)
+	}
+
+	args = l.mergeArgs(args)
+
+	translatedPhrase, tokens, class := l.lookupFallback(func(loc *Locale) (string, []phraseToken, _SpecialTranslationClass) {
+		return loc.lookupVariant(key, variant)
+	})
+
+	switch {
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return l.trMissing(key)
+
+	case class != "":
+		return sptr(class, key)
+
+	case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+		return newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolate()
+
+	default:
+		return translatedPhrase
+	}
+}
+
+/*
+TrRelative returns the relative time phrase for d, like "3 minutes ago"
+(or "in 3 minutes" if d is negative). The biggest time unit that fits into d
+is used (seconds, minutes, hours, days, months, years), and the plural form
+of the phrase is selected for the number of these units like TrCount() does.
+
+The templates are taken from the "__relative__" node of the current Locale
+(and the Locales it inherits), where the phrases of the future are placed
+into the "future" sub node:
+
+        __relative__:
+          minutes:
+            one: "{{count}} minute ago"
+            other: "{{count}} minutes ago"
+          future:
+            minutes:
+              one: "in {{count}} minute"
+              other: "in {{count}} minutes"
+
+English templates are used for the time units that have no templates.
 
-			node = node.subNode(prefix, false)
-			continue
+Nil safe. Returns the same special strings as Tr() does.
+*/
+func (l *Locale) TrRelative(d time.Duration) string {
+
+	unit, n := relativeTimeUnit(d)
+
+	if !l.isValid() {
+		return sptr(_SPTR_LOCALE_IS_NIL, relativeTimeKey(unit, d < 0, DEFAULT_DELIMITER))
+	}
+
+	key := relativeTimeKey(unit, d < 0, l.owner.KeyDelimiter())
+
+	translatedPhrase, tokens, class := l.lookupPlural(key, n)
+	if class != "" {
+		translatedPhrase, tokens = relativeTimeDefault(unit, n, d < 0), nil
+	}
+
+	args := l.mergeArgs(Args{_PLURAL_COUNT_ARG: formatInteger(l.name, n)})
+	return newInterpolator(l, key, translatedPhrase, args).withTokens(tokens).interpolate()
+}
+
+/*
+TrComplex is the same as Tr() but also evaluates the subset
+of ICU MessageFormat of the language phrase, using passed selectors,
+before the interpolation. Supported syntax is:
+
+ - "{name}", replaced by the selector's value,
+ - "{name, select, male {...} female {...} other {...}}",
+ - "{name, plural, =0 {...} one {...} few {...} other {...}}",
+   plural categories are selected by the current Locale's language plural rules,
+   "#" inside the selected message is replaced by the formatted number.
+
+Select and plural arguments may be nested, e.g:
+
+        Inbox:
+          Summary: "{gender, select, male {He has} female {She has} other {They have}}
+            {count, plural, one {# message} other {# messages}} from {{sender}}"
+
+        loc.TrComplex("Inbox/Summary",
+            map[string]interface{}{"gender": "female", "count": 3},
+            privet.Args{"sender": "Bob"})
+        // "She has 3 messages from Bob"
+
+The "other" option is used if there is no option for the selector's value
+or if there is no such selector. Malformed message is interpolated as is.
+
+Nil safe. Returns the same special strings as Tr() does.
+*/
+func (l *Locale) TrComplex(key string, selectors map[string]interface{}, args Args) string {
+
+	if !l.isValid() {
+		return sptr(_SPTR_LOCALE_IS_NIL, key)
+	}
+
+	args = l.mergeArgs(args)
 
-		} else if translatedPhrase, found := node.content[key]; found {
-			if len(args) != 0 {
-				return newInterpolator(translatedPhrase, args).interpolate()
-			} else {
-				return translatedPhrase
+	switch translatedPhrase, _, class := l.lookupCounted(key, args); {
+
+	case class == _SPTR_TRANSLATION_NOT_FOUND:
+		return l.trMissing(key)
+
+	case class != "":
+		return sptr(class, key)
+
+	case len(args) != 0 || hasEscapedVerb(translatedPhrase):
+		translatedPhrase = formatICU(l, translatedPhrase, selectors)
+		return newInterpolator(l, key, translatedPhrase, args).interpolate()
+
+	default:
+		return formatICU(l, translatedPhrase, selectors)
+	}
+}
+
+/*
+Plurals returns all language phrases of plural categories
+(e.g: "one", "few", "many", "other") the key points to (see TrCount()),
+as a map of category to the not interpolated phrase.
+
+It's useful for client-side rendering, when the plural form is selected
+by frontend, using the data exported from the backend.
+
+If the current Locale has no node by the key, the Locales it inherits
+are used. Returns nil if there is no such node or it has no plural phrases.
+
+Nil safe. If this method is called on nil object, nil is returned.
+*/
+func (l *Locale) Plurals(key string) map[string]string {
+
+	var node *localeNode
+	for loc := l; loc.isValid() && node == nil; loc = loc.owner.getLocale(loc.inherits) {
+		node = loc.lookupNode(key)
+		if loc.inherits == "" {
+			break
+		}
+	}
+
+	if node == nil {
+		return nil
+	}
+
+	var plurals map[string]string
+	for _, category := range []string{
+		_PLURAL_ZERO, _PLURAL_ONE, _PLURAL_TWO, _PLURAL_FEW, _PLURAL_MANY, _PLURAL_OTHER,
+	} {
+		if translatedPhrase, found := node.content[category]; found {
+			if plurals == nil {
+				plurals = make(map[string]string)
 			}
+			plurals[category] = translatedPhrase
+		}
+	}
 
-		} else {
-			return sptr(_SPTR_TRANSLATION_NOT_FOUND, originalKey)
+	return plurals
+}
+
+/*
+Verbs returns the interpolation verbs (w/o braces and surrounding spaces,
+e.g: "name", "upper:name", "price|currency") of the language phrase
+the key points to, in order of appearance, w/o duplicates.
+The phrase is looked up the same way Tr() does, but it's not interpolated.
+
+It's useful for translation editors, to ensure the translated phrase
+uses the same set of verbs as the original one.
+
+Returns nil if there is no such phrase or it has no verbs.
+Nil safe. If this method is called on nil object, nil is returned.
+*/
+func (l *Locale) Verbs(key string) []string {
+
+	if !l.isValid() {
+		return nil
+	}
+
+	translatedPhrase, _, class := l.lookupCounted(key, nil)
+	if class != "" {
+		return nil
+	}
+
+	return phraseVerbs(translatedPhrase)
+}
+
+/*
+Keys returns full translation keys of all language phrases of the current Locale
+(not inherited ones), e.g: "Menu/File/Open".
+
+If Config.PreserveKeyOrder was enabled during loading, keys are in order
+they are declared in sources (for each node, the first declaration wins).
+Otherwise keys of each node are sorted lexicographically,
+and sub nodes keys go right after the key of the same name, if any.
+
+Nil safe. If this method is called on nil object, nil is returned.
+*/
+func (l *Locale) Keys() []string {
+
+	if !l.isValid() {
+		return nil
+	}
+
+	keys := make([]string, 0, l.phrasesCount)
+	l.root.walkOrdered(func(key, _ string) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	return keys
+}
+
+/*
+KeysPage returns a page of the lexicographically sorted full translation keys
+of all language phrases of the current Locale (not inherited ones),
+starting from offset and up to limit keys, and the total number of keys.
+It's useful for admin UI browsing the large locales.
+
+The page is empty if offset is out of range or limit <= 0.
+Negative offset is treated as 0.
+
+Nil safe. If this method is called on nil object, nil and 0 are returned.
+*/
+func (l *Locale) KeysPage(offset, limit int) (keys []string, total int) {
+
+	if !l.isValid() {
+		return nil, 0
+	}
+
+	allKeys := make([]string, 0, l.phrasesCount)
+	l.root.applyRecursively(func(node *localeNode) {
+		for key := range node.content {
+			allKeys = append(allKeys, node.fullKey(key))
 		}
+	})
+
+	total = len(allKeys)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= total || limit <= 0 {
+		return []string{}, total
+	}
+
+	sort.Strings(allKeys)
+
+	if limit > total - offset {
+		limit = total - offset
 	}
 
-	return sptr(_SPTR_TRANSLATION_NOT_FOUND, originalKey)
+	keys = make([]string, limit)
+	copy(keys, allKeys[offset:offset+limit])
+
+	return keys, total
+}
+
+/*
+Range calls cb for each language phrase of the current Locale (not inherited ones)
+in the same order Keys() returns, passing the full translation key
+and the not interpolated phrase. Stops as soon as cb returns false.
+
+Nil safe. If this method is called on nil object or cb is nil, there is no-op.
+*/
+func (l *Locale) Range(cb func(key, translatedPhrase string) bool) {
+	if !l.isValid() || cb == nil {
+		return
+	}
+	l.root.walkOrdered(cb)
+}
+
+/*
+LastModified returns the newest last modification time among the files
+that were used to construct the current Locale.
+It's useful for HTTP caching headers ("Last-Modified", "ETag")
+of served translations bundle.
+
+Only the Locale's own sources are considered (not the inherited ones).
+The zero time.Time is returned if Locale is constructed from RAW data only.
+
+Nil safe.
+If this method is called on nil object, the zero time.Time is returned.
+*/
+func (l *Locale) LastModified() time.Time {
+
+	var lastModified time.Time
+
+	if !l.isValid() {
+		return lastModified
+	}
+
+	sources := l.owner.getSources()
+
+	l.root.applyRecursively(func(node *localeNode) {
+		for _, sourceIdx := range node.usedSourcesIdx {
+			if sourceIdx < len(sources) && sources[sourceIdx].modTime.After(lastModified) {
+				lastModified = sources[sourceIdx].modTime
+			}
+		}
+	})
+
+	return lastModified
+}
+
+/*
+TrFunc returns Tr() method bound to the current Locale.
+It's useful for the template engines, that take functions, not objects:
+
+        tmpl.Funcs(template.FuncMap{"tr": loc.TrFunc()})
+
+The returned function is safe for concurrent use.
+
+Nil safe. If this method is called on nil object, the returned function
+returns the same special strings as Tr() of nil Locale does.
+*/
+func (l *Locale) TrFunc() func(key string, args Args) string {
+	return l.Tr
+}
+
+/*
+Prune removes the empty nodes of the current Locale (that have neither
+language phrases nor non-empty nested nodes, e.g. created by "Menu: {}")
+and returns how many nodes are removed.
+So, Keys() and lookups don't walk over dead branches.
+
+WARNING! It's not safe to call Prune() concurrently with any other method
+of the current Locale. Use Config.PruneEmptyNodes to prune all locales
+right at the Load() call instead.
+
+Nil safe. If this method is called on nil object, 0 is returned.
+*/
+func (l *Locale) Prune() int {
+	if !l.isValid() {
+		return 0
+	}
+	if l.base != nil {
+		l = l.base
+	}
+	return l.root.prune()
 }
 
 /*
@@ -102,14 +942,60 @@ func (l *Locale) MarkAsDefault() {
 	if !l.isValid() {
 		return
 	}
+	if l.base != nil {
+		l = l.base
+	}
 	l.owner.setDefaultLocale(l)
 }
 
+/*
+WithArgs returns a view of the current Locale, which Tr() (and other translation
+methods) merges its per-call args over the passed defaults.
+Per-call args have priority.
+
+It's useful for arguments that are the same for many phrases
+(e.g: application's name or user's name), so you don't need to pass them
+to each Tr() call:
+
+        loc := privet.LC("en_US").WithArgs(privet.Args{"app": "Foo"})
+        loc.Tr("Main/Greetings", privet.Args{"name": "Alice"})
+        // both of "app" and "name" are available for interpolation
+
+Neither the passed defaults nor per-call args maps are modified.
+The defaults are copied, so you may reuse the passed map.
+If the current Locale is a view already, defaults are merged over its ones.
+
+Nil safe.
+If this method is called on nil object, nil is returned.
+*/
+func (l *Locale) WithArgs(defaults Args) *Locale {
+	if !l.isValid() {
+		return nil
+	}
+
+	view := *l
+	if view.base == nil {
+		view.base = l
+	}
+
+	view.defaultArgs = make(Args, len(l.defaultArgs) + len(defaults))
+	for name, value := range l.defaultArgs {
+		view.defaultArgs[name] = value
+	}
+	for name, value := range defaults {
+		view.defaultArgs[name] = value
+	}
+
+	return &view
+}
+
 /*
 Name returns the current Locale's name.
 
-Returned name is always in "xx_YY" format, where:
+Returned name is always a canonical locale tag: "xx_YY", "xx_Scrp_YY"
+or just "xx", where:
  - xx is a lower case chars of language name ("en", "ru", "jp"),
+ - Scrp is an optional title case chars of script name ("Hans", "Latn"),
  - YY is a upper case chars of country name ("US", "GB", "RU").
 
 Nil safe.
@@ -0,0 +1,358 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Export returns language phrases of the current Locale (not inherited ones)
+as a document of passed format: "yaml" (or "yml"), "json" or "toml",
+case insensitive. The document has the same tree the phrases are loaded from
+(merged, if they are loaded from more than one source) and the metadata section
+with the locale's name and all its metadata (see metaData()).
+So, the document might be sourced back to get the same Locale.
+
+Keys are sorted lexicographically. Use CanonicalYAML() if you need
+the stable YAML output for diffs, Export() is for debugging and round-tripping.
+
+Returns an error if format is not supported, if some key is both of a phrase
+and a node (which is possible, if they are loaded from different sources),
+or if encoding is failed.
+
+Nil safe. Returns an error if this method is called on nil object.
+*/
+func (l *Locale) Export(format string) ([]byte, *ekaerr.Error) {
+	const s = "Failed to export locale. "
+
+	if !l.isValid() {
+		return nil, ekaerr.IllegalState.
+			New(s + "Locale is nil.").
+			Throw()
+	}
+
+	tree, err := l.tree()
+	if err.IsNotNil() {
+		return nil, err.
+			AddMessage(s).
+			AddFields("privet_locale_name", l.name).
+			Throw()
+	}
+
+	tree["__metadata__"], _ = l.metaData()
+
+	var (
+		data      []byte
+		legacyErr error
+	)
+
+	switch strings.ToLower(strings.TrimSpace(format)) {
+
+	case "yml", "yaml":
+		var b bytes.Buffer
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if legacyErr = encoder.Encode(tree); legacyErr == nil {
+			legacyErr = encoder.Close()
+		}
+		data = b.Bytes()
+
+	case "json":
+		data, legacyErr = json.MarshalIndent(tree, "", "  ")
+
+	case "toml":
+		var tomlTree *toml.Tree
+		if tomlTree, legacyErr = toml.TreeFromMap(tree); legacyErr == nil {
+			var str string
+			str, legacyErr = tomlTree.ToTomlString()
+			data = []byte(str)
+		}
+
+	default:
+		return nil, ekaerr.IllegalArgument.
+			New(s + "Unsupported format. Should be: yaml, json or toml.").
+			AddFields(
+				"privet_locale_name",   l.name,
+				"privet_export_format", format).
+			Throw()
+	}
+
+	if legacyErr != nil {
+		return nil, ekaerr.InternalError.
+			Wrap(legacyErr, s + "Encoder has failed.").
+			AddFields(
+				"privet_locale_name",   l.name,
+				"privet_export_format", format).
+			Throw()
+	}
+
+	return data, nil
+}
+
+/*
+CanonicalYAML returns language phrases of the current Locale (not inherited ones)
+as a YAML document in the canonical form: keys of each node are sorted
+lexicographically, indentation is always 2 spaces, and the metadata section
+(with the locale's name and all its metadata, see metaData()) goes first.
+So, two Locales with the same phrases produce byte-identical output,
+that might be committed to get clean diffs in reviews of translation changes.
+
+Returns an error if some key is both of a phrase and a node (which is possible,
+if they are loaded from different sources), because it can not be represented
+in YAML, or if encoding is failed.
+
+Nil safe. Returns an error if this method is called on nil object.
+*/
+func (l *Locale) CanonicalYAML() ([]byte, *ekaerr.Error) {
+	const s = "Failed to encode locale to the canonical YAML. "
+
+	if !l.isValid() {
+		return nil, ekaerr.IllegalState.
+			New(s + "Locale is nil.").
+			Throw()
+	}
+
+	root, err := l.root.yamlNode()
+	if err.IsNotNil() {
+		return nil, err.
+			AddMessage(s).
+			AddFields("privet_locale_name", l.name).
+			Throw()
+	}
+
+	metaData, metaDataKeys := l.metaData()
+
+	metaDataNode := yamlMappingNode()
+	for _, key := range metaDataKeys {
+		yamlAppend(metaDataNode, key, yamlValueNode(metaData[key]))
+	}
+
+	root.Content = append([]*yaml.Node{yamlScalarNode("__metadata__"), metaDataNode}, root.Content...)
+
+	var b bytes.Buffer
+
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	if legacyErr := encoder.Encode(root); legacyErr != nil {
+		return nil, ekaerr.InternalError.
+			Wrap(legacyErr, s + "YAML encoder has failed.").
+			AddFields("privet_locale_name", l.name).
+			Throw()
+	}
+
+	if legacyErr := encoder.Close(); legacyErr != nil {
+		return nil, ekaerr.InternalError.
+			Wrap(legacyErr, s + "YAML encoder has failed.").
+			AddFields("privet_locale_name", l.name).
+			Throw()
+	}
+
+	return b.Bytes(), nil
+}
+
+/*
+metaData returns the metadata of the current Locale as the fields
+of metadata section (see SourceItem.loadMetaData()): the locale's name,
+its parent, fallbacks, list formatting rules and required args, if any,
+and the keys of these fields in the order they should be encoded.
+
+The locale's name is saved by the first of the keys the Client looks it up by
+(see Client.SetMetadataLocaleKeys()), or by "locale" by default,
+so it's sourced back even if Config.StrictMetadata is enabled.
+*/
+func (l *Locale) metaData() (map[string]interface{}, []string) {
+
+	localeKey := "locale"
+	if localeKeys := (*[]string)(atomic.LoadPointer(&l.owner.metaDataLocaleKeys)); localeKeys != nil {
+		localeKey = (*localeKeys)[0]
+	}
+
+	var (
+		metaData = map[string]interface{}{localeKey: l.name}
+		keys     = []string{localeKey}
+	)
+
+	add := func(key string, value interface{}) {
+		metaData[key] = value
+		keys = append(keys, key)
+	}
+
+	if l.inherits != "" {
+		add("inherits", l.inherits)
+	}
+	if len(l.fallbacks) != 0 {
+		add("fallbacks", l.fallbacks)
+	}
+	if l.listFormat.separator != "" {
+		add("list_separator", l.listFormat.separator)
+	}
+	if l.listFormat.conjunction != "" {
+		add("list_conjunction", l.listFormat.conjunction)
+	}
+	if len(l.requiredArgs) != 0 {
+		requiredArgs := make(map[string]interface{}, len(l.requiredArgs))
+		for key, args := range l.requiredArgs {
+			requiredArgs[key] = args
+		}
+		add("required_args", requiredArgs)
+	}
+
+	return metaData, keys
+}
+
+/*
+tree returns the language phrases of the current Locale (not inherited ones)
+as the nested maps, that is the inverse of localeNode.scan().
+Returns an error if some key is both of a phrase and a node.
+*/
+func (l *Locale) tree() (map[string]interface{}, *ekaerr.Error) {
+
+	var (
+		root = make(map[string]interface{})
+		err  *ekaerr.Error
+	)
+
+	// Parent nodes are always processed before their sub nodes,
+	// so the clash of the names is found before the sub node's map is created.
+
+	l.root.applyRecursively(func(node *localeNode) {
+		if err.IsNotNil() {
+			return
+		}
+
+		m := root
+		for _, name := range node.path {
+			subMap, isExist := m[name].(map[string]interface{})
+			if !isExist {
+				subMap = make(map[string]interface{})
+				m[name] = subMap
+			}
+			m = subMap
+		}
+
+		for name, translatedPhrase := range node.content {
+			if _, isSubNode := node.subNodes[name]; isSubNode {
+				err = ekaerr.IllegalState.
+					New("Key is both of a phrase and a node.").
+					AddFields("privet_key", node.fullKey(name)).
+					Throw()
+				return
+			}
+			m[name] = translatedPhrase
+		}
+	})
+
+	if err.IsNotNil() {
+		return nil, err.
+			Throw()
+	}
+
+	return root, nil
+}
+
+/*
+yamlNode returns the YAML mapping node of the language phrases
+and the sub nodes of the current localeNode, sorted by their names.
+Returns an error if some name is both of a phrase and a sub node.
+*/
+func (n *localeNode) yamlNode() (*yaml.Node, *ekaerr.Error) {
+
+	names := make([]string, 0, len(n.content) + len(n.subNodes))
+	for name := range n.content {
+		if _, isSubNode := n.subNodes[name]; isSubNode {
+			return nil, ekaerr.IllegalState.
+				New("Key is both of a phrase and a node.").
+				AddFields("privet_key", n.fullKey(name)).
+				Throw()
+		}
+		names = append(names, name)
+	}
+	for name := range n.subNodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	node := yamlMappingNode()
+	for _, name := range names {
+		if translatedPhrase, isPhrase := n.content[name]; isPhrase {
+			yamlAppend(node, name, yamlScalarNode(translatedPhrase))
+			continue
+		}
+		subNode, err := n.subNodes[name].yamlNode()
+		if err.IsNotNil() {
+			return nil, err.
+				Throw()
+		}
+		yamlAppend(node, name, subNode)
+	}
+
+	return node, nil
+}
+
+/*
+yamlValueNode returns the YAML node of passed metadata's value (see Locale.metaData()):
+a string scalar, a flow sequence of strings or a mapping sorted by the keys.
+*/
+func yamlValueNode(value interface{}) *yaml.Node {
+
+	switch typedValue := value.(type) {
+
+	case []string:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		for _, elem := range typedValue {
+			node.Content = append(node.Content, yamlScalarNode(elem))
+		}
+		return node
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedValue))
+		for key := range typedValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		node := yamlMappingNode()
+		for _, key := range keys {
+			yamlAppend(node, key, yamlValueNode(typedValue[key]))
+		}
+		return node
+
+	default:
+		return yamlScalarNode(typedValue.(string))
+	}
+}
+
+/*
+yamlMappingNode returns a new empty YAML mapping node.
+*/
+func yamlMappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+/*
+yamlScalarNode returns a new YAML string scalar node with passed value.
+*/
+func yamlScalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+/*
+yamlAppend appends passed key and value to the YAML mapping node.
+*/
+func yamlAppend(mapping *yaml.Node, key string, value *yaml.Node) {
+	mapping.Content = append(mapping.Content, yamlScalarNode(key), value)
+}
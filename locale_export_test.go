@@ -0,0 +1,94 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocaleExportRoundTrip(t *testing.T) {
+
+	const content = `
+__metadata__:
+  lang: ru_RU
+  inherits: ru
+  fallbacks: [en_US]
+  list_separator: "; "
+  list_conjunction: " и "
+  required_args:
+    greeting: [name]
+greeting: "Привет, {{name}}"
+Menu:
+  File: Файл
+`
+
+	newClient := func() *Client {
+		c := new(Client)
+		c.SetMetadataLocaleKeys("lang")
+		c.SetStrictMetadata(true)
+		return c
+	}
+
+	c := newClient()
+	if err := c.Source(
+		RawSource{Format: "yaml", Data: []byte(content)},
+		RawSource{Format: "yaml", Data: []byte("__metadata__:\n  lang: ru\nother: Другое\n")},
+	); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+
+	loc := c.LC("ru_RU")
+
+	for _, format := range []string{"yaml", "json", "toml", "canonical"} {
+		var (
+			data         []byte
+			isFailed     bool
+			sourceFormat = format
+		)
+
+		if format == "canonical" {
+			exported, exportErr := loc.CanonicalYAML()
+			data, isFailed = exported, exportErr.IsNotNil()
+			sourceFormat = "yaml"
+		} else {
+			exported, exportErr := loc.Export(format)
+			data, isFailed = exported, exportErr.IsNotNil()
+		}
+		if isFailed {
+			t.Errorf("%s: failed to export", format)
+			continue
+		}
+
+		other := newClient()
+		if err := other.Source(
+			RawSource{Format: sourceFormat, Data: data},
+			RawSource{Format: "yaml", Data: []byte("__metadata__:\n  lang: ru\nother: Другое\n")},
+		); err.IsNotNil() {
+			t.Errorf("%s: failed to source exported %s", format, data)
+			continue
+		}
+		if err := other.Load(); err.IsNotNil() {
+			t.Errorf("%s: failed to load exported %s", format, data)
+			continue
+		}
+
+		otherLoc := other.LC("ru_RU")
+
+		switch {
+		case !reflect.DeepEqual(otherLoc.flatten(), loc.flatten()):
+			t.Errorf("%s: phrases = %v, expected %v", format, otherLoc.flatten(), loc.flatten())
+		case otherLoc.inherits != loc.inherits ||
+			!reflect.DeepEqual(otherLoc.fallbacks, loc.fallbacks) ||
+			otherLoc.listFormat != loc.listFormat ||
+			!reflect.DeepEqual(otherLoc.requiredArgs, loc.requiredArgs):
+			t.Errorf("%s: metadata is not the same, exported %s", format, data)
+		}
+	}
+}
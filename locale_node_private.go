@@ -6,6 +6,7 @@
 package privet
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 
@@ -40,13 +41,20 @@ type (
 	(depends of Client's state - either sources under loading or not),
 	meaning that sources with these indexes were used
 	to construct EXACTLY current node (content), neither nested nor parented.
+	origins contains the same index of source for each phrase of content,
+	the phrase is taken from (the last one, if it's overwritten).
 	*/
 	localeNode struct {
 		parent         *Locale
+		key            string   // full translation key of node joined by DEFAULT_DELIMITER, empty for root
+		path           []string // names of nodes from the root to this one, nil for root
 		subNodes       map[string]*localeNode
 		content        map[string]string
+		compiled       map[string][]phraseToken // tokens of content's phrases, see compilePhrase()
 		contentTmp     map[string]string
+		origins        map[string]int
 		usedSourcesIdx []int
+		order          []string // names of phrases and sub nodes in declaration order
 	}
 )
 
@@ -64,12 +72,125 @@ func (n *localeNode) subNode(name string, createIfNotExist bool) *localeNode {
 
 	if subNode == nil && createIfNotExist {
 		subNode = n.parent.makeSubNode()
+		subNode.key = joinKey(n.key, name)
+		subNode.path = append(n.path[:len(n.path):len(n.path)], name)
 		n.subNodes[name] = subNode
 	}
 
 	return subNode
 }
 
+/*
+clone returns a deep copy of the current localeNode and all its sub nodes,
+that belong to the passed Locale. contentTmp of copies are empty.
+*/
+func (n *localeNode) clone(parent *Locale) *localeNode {
+
+	cloned := parent.makeSubNode()
+	cloned.key = n.key
+	cloned.path = n.path
+
+	for key, translatedPhrase := range n.content {
+		cloned.content[key] = translatedPhrase
+	}
+	for key, originIdx := range n.origins {
+		cloned.origins[key] = originIdx
+	}
+	for name, subNode := range n.subNodes {
+		cloned.subNodes[name] = subNode.clone(parent)
+	}
+
+	cloned.usedSourcesIdx = append([]int(nil), n.usedSourcesIdx...)
+	cloned.order = append([]string(nil), n.order...)
+
+	return cloned
+}
+
+/*
+merge copies the language phrases and the sub nodes (recursively) of other localeNode
+to the current one, creating the sub nodes if they are not exist,
+and returns the number of phrases the current localeNode (with its sub nodes)
+didn't have before. The phrases that are exist already are replaced
+only if overwrite is true.
+
+other's indexes of sources (see usedSourcesIdx, origins) are shifted
+by sourcesOffset, because other's sources are appended to the current ones.
+*/
+func (n *localeNode) merge(other *localeNode, sourcesOffset int, overwrite bool) uint64 {
+
+	var added uint64
+
+	for key, translatedPhrase := range other.content {
+		_, isExist := n.content[key]
+		if isExist && !overwrite {
+			continue
+		}
+		if !isExist {
+			added++
+		}
+		n.content[key] = translatedPhrase
+		if originIdx, hasOrigin := other.origins[key]; hasOrigin {
+			n.origins[key] = originIdx + sourcesOffset
+		} else {
+			delete(n.origins, key)
+		}
+	}
+
+	for _, usedSourceIdx := range other.usedSourcesIdx {
+		n.usedSourcesIdx = append(n.usedSourcesIdx, usedSourceIdx + sourcesOffset)
+	}
+
+outer:
+	for _, name := range other.order {
+		for _, knownName := range n.order {
+			if knownName == name {
+				continue outer
+			}
+		}
+		n.order = append(n.order, name)
+	}
+
+	for name, otherSubNode := range other.subNodes {
+		added += n.subNode(name, true).merge(otherSubNode, sourcesOffset, overwrite)
+	}
+
+	return added
+}
+
+/*
+prune removes the sub nodes (bottom-up) of the current localeNode
+that have neither language phrases nor sub nodes,
+and returns the number of removed ones. The current localeNode is never removed.
+*/
+func (n *localeNode) prune() int {
+
+	removed := 0
+	for name, subNode := range n.subNodes {
+		removed += subNode.prune()
+		if len(subNode.content) == 0 && len(subNode.subNodes) == 0 {
+			delete(n.subNodes, name)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+/*
+fullKey returns a full translation key for the passed key of the current localeNode,
+meaning that the names of nodes from the root to the current localeNode
+and passed key are joined by Client.KeyDelimiter().
+*/
+func (n *localeNode) fullKey(key string) string {
+
+	if len(n.path) == 0 {
+		return key
+	}
+
+	delimiter := string(n.parent.owner.KeyDelimiter())
+	return strings.Join(n.path, delimiter) + delimiter + key
+}
+
 /*
 applyRecursively calls passed callback cb passing the current localeNode,
 treating it as a root, and then doing the same work for each localeNode from
@@ -162,24 +283,46 @@ doing next things:
    will be either extracted from the subNodes or created an empty new one,
    and scan() will be called recursively for that sub localeNode and that map.
 
+ - If a value is an array ([]interface{}) of basic Golang types,
+   its elements are joined by Client's array separator (see Client.SetArraySeparator())
+   and saved as one value using store() method.
+
  - If a value has any other type,
-   it's an error, even if it's array (arrays are prohibited).
+   it's an error, even if it's array of maps or arrays.
 
 sourceItemIdx will be saved to the usedSourcesIdx,
 after the whole map is successfully parsed and if there is no the same index yet.
+
+sourceKey is the key of passed map as it's declared in the source
+(joined by DEFAULT_DELIMITER, empty for root), before keys are transformed
+(see Client.SetKeyTransform()). The declared keys order is looked up by it.
 */
 func (n *localeNode) scan(
 
 	from          map[string]interface{},
 	sourceItemIdx int,
-	overwrite     bool,
+	sourceKey     string,
+	opts          *loadOptions,
 
 ) *ekaerr.Error {
 
 	const s = "Failed to scan a key-value component."
 
 	var err *ekaerr.Error
-	for key, value := range from {
+	for originalKey, value := range from {
+
+		if opts.isIgnoredKey(originalKey) {
+			continue
+		}
+
+		isNode := reflect2.RTypeOf(value) == ekaunsafe.RTypeMapStringInterface()
+		key := opts.transformKey(originalKey, isNode)
+
+		if strings.ContainsAny(key, opts.keyDelimiters) {
+			n.warn(key, sourceItemIdx,
+				"Key contains the key delimiter, so it is unreachable by Tr().")
+		}
+
 		switch rtype := reflect2.RTypeOf(value); {
 
 		case key == "":
@@ -187,10 +330,10 @@ func (n *localeNode) scan(
 				New(s + "Key is empty.")
 
 		case rtype == 0:
-			err = n.store(key, "<undefined>", overwrite)
+			err = n.store(key, "<undefined>", sourceItemIdx, opts)
 
 		case rtype == ekaunsafe.RTypeString():
-			err = n.store(key, value.(string), overwrite)
+			err = n.store(key, value.(string), sourceItemIdx, opts)
 
 		case rtype == ekaunsafe.RTypeBool():
 			b := *(*bool)(ekaunsafe.TakeRealAddr(value))
@@ -198,15 +341,15 @@ func (n *localeNode) scan(
 			if b {
 				value = "true"
 			}
-			err = n.store(key, value, overwrite)
+			err = n.store(key, value, sourceItemIdx, opts)
 
 		case ekaunsafe.RTypeIsIntAny(rtype):
 			i64 := *(*int64)(ekaunsafe.TakeRealAddr(value))
-			err = n.store(key, strconv.FormatInt(i64, 10), overwrite)
+			err = n.store(key, strconv.FormatInt(i64, 10), sourceItemIdx, opts)
 
 		case ekaunsafe.RTypeIsUintAny(rtype):
 			u64 := *(*uint64)(ekaunsafe.TakeRealAddr(value))
-			err = n.store(key, strconv.FormatUint(u64, 10), overwrite)
+			err = n.store(key, strconv.FormatUint(u64, 10), sourceItemIdx, opts)
 
 		case ekaunsafe.RTypeIsFloatAny(rtype):
 			f64 := *(*float64)(ekaunsafe.TakeRealAddr(value))
@@ -214,13 +357,20 @@ func (n *localeNode) scan(
 			if rtype == ekaunsafe.RTypeFloat64() {
 				bitSize = 64
 			}
-			err = n.store(key, strconv.FormatFloat(f64, 'f', 2, bitSize), overwrite)
+			err = n.store(key, strconv.FormatFloat(f64, 'f', 2, bitSize), sourceItemIdx, opts)
 
 		case rtype == ekaunsafe.RTypeMapStringInterface():
 			embeddedMap := value.(map[string]interface{})
-			err = n.subNode(key, true).scan(embeddedMap, sourceItemIdx, overwrite)
+			err = n.subNode(key, true).scan(embeddedMap, sourceItemIdx, joinKey(sourceKey, originalKey), opts)
 
 		default:
+			if arr, isArray := value.([]interface{}); isArray {
+				var joined string
+				if joined, err = joinArray(arr, opts.arraySeparator); err.IsNil() {
+					err = n.store(key, joined, sourceItemIdx, opts)
+				}
+				break
+			}
 			err = ekaerr.IllegalFormat.
 				New(s + "Unexpected type of value.").
 				AddFields("privet_source_value_type", reflect2.TypeOf(value).String())
@@ -230,7 +380,7 @@ func (n *localeNode) scan(
 		if err.IsNotNil() {
 			return err.
 				AddMessage(s).
-				AddFields("privet_source_key", key).
+				AddFields("privet_source_key", originalKey).
 				Throw()
 		}
 	}
@@ -250,24 +400,195 @@ func (n *localeNode) scan(
 		n.usedSourcesIdx = append(n.usedSourcesIdx, sourceItemIdx)
 	}
 
+	if opts.keepKeyOrder {
+		n.rememberOrder(from, n.parent.owner.sourcesTmp[sourceItemIdx].keyOrder[sourceKey], opts)
+	}
+
 	return nil
 }
 
 /*
-store saves passed key, value to the contentTmp map,
-if there is no the same key yet in content map, or if overwriting is allowed.
+warn adds a LoadWarning with passed message about passed key of the current localeNode,
+found in the source placed in sourcesTmp by sourceItemIdx index,
+to the Client's LoadReport.
+*/
+func (n *localeNode) warn(key string, sourceItemIdx int, message string) {
+	owner := n.parent.owner
+	owner.reportTmp.Warnings = append(owner.reportTmp.Warnings, LoadWarning{
+		LocaleName: n.parent.name,
+		Key:        n.fullKey(key),
+		Source:     owner.sourcesTmp[sourceItemIdx].Path,
+		Message:    message,
+	})
+}
 
-Returns an error if overwriting is prohibited and it's a duplication.
+/*
+rememberOrder appends the names from declared (that are also presented in from)
+to the order of the current localeNode, if they are not there yet.
+So, the names are kept in order they are declared the first time.
+Names are transformed the same way as scan() does.
 */
-func (n *localeNode) store(key, value string, overwrite bool) *ekaerr.Error {
+func (n *localeNode) rememberOrder(
+
+	from     map[string]interface{},
+	declared []string,
+	opts     *loadOptions,
 
-	// contentTmp contains only the current file processing keys;
-	// it will be so strange (and impossible), if there will be the same keys.
+) {
+
+	alreadyOrdered := make(map[string]struct{}, len(n.order))
+	for _, name := range n.order {
+		alreadyOrdered[name] = struct{}{}
+	}
+
+	for _, name := range declared {
+		value, isExist := from[name]
+		if !isExist {
+			continue
+		}
+		name = opts.transformKey(name, reflect2.RTypeOf(value) == ekaunsafe.RTypeMapStringInterface())
+		if _, isOrdered := alreadyOrdered[name]; !isOrdered {
+			n.order = append(n.order, name)
+			alreadyOrdered[name] = struct{}{}
+		}
+	}
+}
+
+/*
+orderedNames returns the names of all phrases and sub nodes of the current localeNode.
+Names from the order go first (see Config.PreserveKeyOrder),
+the rest of names are sorted lexicographically.
+*/
+func (n *localeNode) orderedNames() []string {
+
+	names := make([]string, 0, len(n.content) + len(n.subNodes))
+	seen := make(map[string]struct{}, cap(names))
+
+	for _, name := range n.order {
+		_, isPhrase := n.content[name]
+		_, isSubNode := n.subNodes[name]
+		if _, isSeen := seen[name]; !isSeen && (isPhrase || isSubNode) {
+			names = append(names, name)
+			seen[name] = struct{}{}
+		}
+	}
+
+	ordered := len(names)
+
+	for name := range n.content {
+		if _, isSeen := seen[name]; !isSeen {
+			names = append(names, name)
+			seen[name] = struct{}{}
+		}
+	}
+	for name := range n.subNodes {
+		if _, isSeen := seen[name]; !isSeen {
+			names = append(names, name)
+			seen[name] = struct{}{}
+		}
+	}
+
+	sort.Strings(names[ordered:])
+	return names
+}
+
+/*
+walkOrdered calls cb for each language phrase of the current localeNode
+and its sub nodes (depth-first) in order of orderedNames(),
+passing a full translation key and the phrase.
+If a name is both of phrase and sub node, the phrase goes first.
+Stops and returns false as soon as cb returns false.
+*/
+func (n *localeNode) walkOrdered(cb func(key, translatedPhrase string) bool) bool {
+
+	for _, name := range n.orderedNames() {
+		if translatedPhrase, isPhrase := n.content[name]; isPhrase {
+			if !cb(n.fullKey(name), translatedPhrase) {
+				return false
+			}
+		}
+		if subNode := n.subNodes[name]; subNode != nil && !subNode.walkOrdered(cb) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+store saves passed key, value to the contentTmp map,
+if there is no the same key yet in content map, or if overwriting is allowed
+either by Config.OverwriteExistingKey or by SourceOptions.Overwrite of the source.
+value is trimmed and its whitespaces are collapsed, if Config.TrimPhrases
+and Config.CollapsePhraseSpaces are enabled respectively.
+
+Returns an error if overwriting is prohibited and it's a duplication,
+or if the same source has the key already (see Client.SetKeyTransform()).
+If Config.ContinueOnSourceError is enabled, the duplication is not an error,
+it's added to the Client's LoadReport instead, keeping the old value.
+*/
+func (n *localeNode) store(
+
+	key, value    string,
+	sourceItemIdx int,
+	opts          *loadOptions,
+
+) *ekaerr.Error {
+
+	if opts.trimPhrases {
+		value = strings.TrimSpace(value)
+	}
+	if opts.collapsePhraseSpaces {
+		value = collapseSpaces(value)
+	}
+
+	// contentTmp contains only the current source's keys. The source can't have
+	// the same key twice, but two keys may become the same by KeyTransform
+	// (e.g: "Title" and "title" lower cased). It's a duplication, no matter
+	// whether overwriting is allowed, because the order of keys is random.
+
+	owner := n.parent.owner
+
+	if newValue, isExist := n.contentTmp[key]; isExist {
+		newSource := owner.sourcesTmp[sourceItemIdx].Path
+		if opts.continueOnError {
+			owner.reportTmp.Conflicts = append(owner.reportTmp.Conflicts, LoadConflict{
+				LocaleName: n.parent.name,
+				Key:        n.fullKey(key),
+				OldValue:   newValue,
+				NewValue:   value,
+				OldSources: []string{newSource},
+				NewSource:  newSource,
+			})
+			return nil
+		}
+		return ekaerr.AlreadyExist.
+			New("Failed to add new translation phrase. Keys of the source are the same after transforming.").
+			AddFields(
+				"privet_source_applied",   newSource,
+				"privet_source_key",       key,
+				"privet_source_new_value", value,
+				"privet_source_old_value", newValue).
+			Throw()
+	}
+
+	if _, isExist := n.content[key]; isExist &&
+		!opts.overwrite && !owner.sourcesTmp[sourceItemIdx].opts.Overwrite {
 
-	if _, isExist := n.content[key]; isExist && !overwrite {
 		alreadyUsedSources := make([]string, len(n.usedSourcesIdx))
 		for i, usedSourceIdx := range n.usedSourcesIdx {
-			alreadyUsedSources[i] = n.parent.owner.sourcesTmp[usedSourceIdx].Path
+			alreadyUsedSources[i] = owner.sourcesTmp[usedSourceIdx].Path
+		}
+		if opts.continueOnError {
+			owner.reportTmp.Conflicts = append(owner.reportTmp.Conflicts, LoadConflict{
+				LocaleName: n.parent.name,
+				Key:        n.fullKey(key),
+				OldValue:   n.content[key],
+				NewValue:   value,
+				OldSources: alreadyUsedSources,
+				NewSource:  owner.sourcesTmp[sourceItemIdx].Path,
+			})
+			return nil
 		}
 		return ekaerr.AlreadyExist.
 			New("Failed to add new translation phrase. Already exist.").
@@ -282,3 +603,44 @@ func (n *localeNode) store(key, value string, overwrite bool) *ekaerr.Error {
 	n.contentTmp[key] = value
 	return nil
 }
+
+/*
+joinArray returns the elements of arr joined by separator, if all of them are
+basic Golang types (string, bool, int, uint, float, nil), the same way as scan()
+stores them. Returns an error, if arr contains an element of any other type
+(e.g: map or array).
+*/
+func joinArray(arr []interface{}, separator string) (string, *ekaerr.Error) {
+
+	elems := make([]string, len(arr))
+
+	for i, value := range arr {
+		switch typedValue := value.(type) {
+		case nil:
+			elems[i] = "<undefined>"
+		case string:
+			elems[i] = typedValue
+		case bool:
+			elems[i] = strconv.FormatBool(typedValue)
+		case int:
+			elems[i] = strconv.FormatInt(int64(typedValue), 10)
+		case int64:
+			elems[i] = strconv.FormatInt(typedValue, 10)
+		case uint64:
+			elems[i] = strconv.FormatUint(typedValue, 10)
+		case float32:
+			elems[i] = strconv.FormatFloat(float64(typedValue), 'f', 2, 32)
+		case float64:
+			elems[i] = strconv.FormatFloat(typedValue, 'f', 2, 64)
+		default:
+			return "", ekaerr.IllegalFormat.
+				New("Array must contain only strings, numbers, bools or nulls.").
+				AddFields(
+					"privet_source_array_index",      i,
+					"privet_source_array_value_type", reflect2.TypeOf(value).String()).
+				Throw()
+		}
+	}
+
+	return strings.Join(elems, separator), nil
+}
@@ -5,6 +5,20 @@
 
 package privet
 
+import (
+	"strings"
+	"sync/atomic"
+)
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	/*
+	_VARIANT_DEFAULT is a name of variant, which phrase is used by Locale.TrVariant()
+	if there is no phrase of the requested variant and the key points to the node.
+	*/
+	_VARIANT_DEFAULT = "default"
+)
+
 /*
 isValid ensures that the current Locale object is not nil and initialized correctly
 (not manually instantiated by the caller). Returns true if this is correct object.
@@ -24,6 +38,513 @@ func (l *Locale) makeSubNode() *localeNode {
 		subNodes:       make(map[string]*localeNode),
 		content:        make(map[string]string),
 		contentTmp:     make(map[string]string),
+		origins:        make(map[string]int),
 		usedSourcesIdx: nil,
 	}
 }
+
+/*
+flatten returns all language phrases of the current Locale (not inherited ones)
+as a map of full translation key to the language phrase.
+
+Requirements:
+ - Current Locale is valid (isValid() returns true), panic otherwise.
+*/
+func (l *Locale) flatten() map[string]string {
+
+	flat := make(map[string]string, l.phrasesCount)
+
+	l.root.applyRecursively(func(node *localeNode) {
+		for key, translatedPhrase := range node.content {
+			flat[node.fullKey(key)] = translatedPhrase
+		}
+	})
+
+	return flat
+}
+
+/*
+clone returns a deep copy of the current Locale (including all its localeNode s),
+so the new language phrases could be merged into the copy,
+w/o affecting the current Locale, that still may be in use.
+The copy has an empty contentTmp in each node.
+
+Requirements:
+ - Current Locale is valid (isValid() returns true), panic otherwise.
+ - Current Locale is not a WithArgs() view.
+*/
+func (l *Locale) clone() *Locale {
+
+	cloned := &Locale{
+		owner:        l.owner,
+		name:         l.name,
+		inherits:     l.inherits,
+		fallbacks:    l.fallbacks,
+		listFormat:   l.listFormat,
+		phrasesCount: l.phrasesCount,
+	}
+
+	if len(l.requiredArgs) != 0 {
+		cloned.requiredArgs = make(map[string][]string, len(l.requiredArgs))
+		for key, requiredArgs := range l.requiredArgs {
+			cloned.requiredArgs[key] = requiredArgs
+		}
+	}
+
+	cloned.root = l.root.clone(cloned)
+	return cloned
+}
+
+/*
+merge copies the language phrases (see localeNode.merge()) and the metadata
+of other Locale to the current one. Metadata's fields of the current Locale
+(parent, fallbacks, list formatting rules) are replaced by other's ones,
+if they are empty or if overwrite is true. Required args are taken along with
+the phrases they are declared for.
+
+Requirements:
+ - Both of Locales are valid (isValid() returns true), panic otherwise.
+ - Current Locale is not in use yet (e.g: it's a clone()'s copy).
+*/
+func (l *Locale) merge(other *Locale, sourcesOffset int, overwrite bool) {
+
+	if other.inherits != "" && (l.inherits == "" || overwrite) {
+		l.inherits = other.inherits
+	}
+	if len(other.fallbacks) != 0 && (len(l.fallbacks) == 0 || overwrite) {
+		l.fallbacks = other.fallbacks
+	}
+	if other.listFormat.separator != "" && (l.listFormat.separator == "" || overwrite) {
+		l.listFormat.separator = other.listFormat.separator
+	}
+	if other.listFormat.conjunction != "" && (l.listFormat.conjunction == "" || overwrite) {
+		l.listFormat.conjunction = other.listFormat.conjunction
+	}
+
+	// Required args belong to the phrase they are declared for,
+	// so they are taken from the Locale which phrase is kept.
+
+	if overwrite {
+		for key := range l.requiredArgs {
+			if _, _, class := other.lookup(key); class == "" {
+				delete(l.requiredArgs, key)
+			}
+		}
+	}
+
+	for key, requiredArgs := range other.requiredArgs {
+		if _, _, class := l.lookup(key); class == "" && !overwrite {
+			continue
+		}
+		if l.requiredArgs == nil {
+			l.requiredArgs = make(map[string][]string)
+		}
+		l.requiredArgs[key] = requiredArgs
+	}
+
+	l.phrasesCount += l.root.merge(other.root, sourcesOffset, overwrite)
+}
+
+/*
+mergeArgs returns args merged over the current Locale's default args
+(see WithArgs()). Neither args nor default args are modified,
+a new Args is created if there is something to merge.
+*/
+func (l *Locale) mergeArgs(args Args) Args {
+
+	switch {
+	case len(l.defaultArgs) == 0:
+		return args
+	case len(args) == 0:
+		return l.defaultArgs
+	}
+
+	merged := make(Args, len(l.defaultArgs) + len(args))
+	for name, value := range l.defaultArgs {
+		merged[name] = value
+	}
+	for name, value := range args {
+		merged[name] = value
+	}
+
+	return merged
+}
+
+/*
+lookup walks the localeNode tree starting from the root,
+splitting the passed translation key by the key delimiters (see Client.SetKeyDelimiters()),
+and returns the language phrase it points to, along with its tokens
+(see compilePhrase()), if the phrase is compiled (see Config.CompilePhrases).
+
+If the phrase is not found or key is malformed, an empty string is returned
+and the 3rd returned value is a class of special translation string
+that describes what's wrong. It's empty if the phrase is found.
+If Config.StrictKeyPath is enabled and the key points to the node (not a phrase),
+it's _SPTR_TRANSLATION_KEY_IS_NODE.
+
+Requirements:
+ - Current Locale is valid (isValid() returns true), panic otherwise.
+*/
+func (l *Locale) lookup(key string) (string, []phraseToken, _SpecialTranslationClass) {
+
+	delimiters := l.owner.getKeyDelimiters()
+
+	if key != "" && atomic.LoadUint32(&l.owner.config.CollapseEmptyKeySegments) == 1 {
+		key = collapseKeyDelimiters(key, delimiters)
+	}
+
+	if key == "" {
+		return "", nil, _SPTR_TRANSLATION_KEY_IS_EMPTY
+	}
+
+	// Fast path for the flat (single-segment) keys of the root node.
+	// The key with a delimiter is never looked up as is, even if the root node
+	// has such phrase (see LoadWarning), so the result is the same as of the loop below.
+
+	if indexKeyDelimiter(key, delimiters) == -1 {
+		if translatedPhrase, found := l.root.content[key]; found {
+			return translatedPhrase, l.root.compiled[key], ""
+		}
+	}
+
+	var prefix string
+
+	for node := l.root; node != nil; {
+		if idx := indexKeyDelimiter(key, delimiters); idx != -1 {
+			prefix, key = key[:idx], key[idx+1:]
+
+			if len(key) == 0 || len(prefix) == 0 {
+				return "", nil, _SPTR_TRANSLATION_KEY_IS_INCORRECT
+			}
+
+			node = node.subNode(prefix, false)
+			continue
+
+		} else if translatedPhrase, found := node.content[key]; found {
+			return translatedPhrase, node.compiled[key], ""
+
+		} else if node.subNodes[key] != nil &&
+			atomic.LoadUint32(&l.owner.config.StrictKeyPath) == 1 {
+
+			return "", nil, _SPTR_TRANSLATION_KEY_IS_NODE
+
+		} else {
+			return "", nil, _SPTR_TRANSLATION_NOT_FOUND
+		}
+	}
+
+	return "", nil, _SPTR_TRANSLATION_NOT_FOUND
+}
+
+/*
+lookupNode is the same as lookup() but returns a localeNode the key points to
+(e.g: node "File" for "Menu/File"), or nil if there is no such node
+or key is malformed.
+*/
+func (l *Locale) lookupNode(key string) *localeNode {
+
+	delimiters := l.owner.getKeyDelimiters()
+
+	if key != "" && atomic.LoadUint32(&l.owner.config.CollapseEmptyKeySegments) == 1 {
+		key = collapseKeyDelimiters(key, delimiters)
+	}
+
+	if key == "" {
+		return nil
+	}
+
+	node := l.root
+	for node != nil && key != "" {
+		var prefix string
+		if idx := indexKeyDelimiter(key, delimiters); idx != -1 {
+			prefix, key = key[:idx], key[idx+1:]
+			if key == "" {
+				return nil
+			}
+		} else {
+			prefix, key = key, ""
+		}
+		if prefix == "" {
+			return nil
+		}
+		node = node.subNode(prefix, false)
+	}
+
+	return node
+}
+
+/*
+indexKeyDelimiter returns the index of the first of any delimiters in key,
+or -1 if there is no one.
+*/
+func indexKeyDelimiter(key, delimiters string) int {
+	if len(delimiters) == 1 {
+		return strings.IndexByte(key, delimiters[0])
+	}
+	return strings.IndexAny(key, delimiters)
+}
+
+/*
+collapseKeyDelimiters returns key with removed leading and trailing delimiters
+and with each sequence of delimiters collapsed to the one, the first of the sequence
+(e.g: "/a//b/" -> "a/b"). The key is returned as is, if there is nothing to collapse.
+*/
+func collapseKeyDelimiters(key, delimiters string) string {
+
+	isDelimiter := func(c byte) bool {
+		return strings.IndexByte(delimiters, c) != -1
+	}
+
+	hasSequence := false
+	for i, n := 1, len(key); i < n && !hasSequence; i++ {
+		hasSequence = isDelimiter(key[i-1]) && isDelimiter(key[i])
+	}
+
+	if !isDelimiter(key[0]) && !isDelimiter(key[len(key)-1]) && !hasSequence {
+		return key
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(key))
+
+	for i, n := 0, len(key); i < n; i++ {
+		if !isDelimiter(key[i]) {
+			sb.WriteByte(key[i])
+		} else if sb.Len() != 0 && !isDelimiter(key[i-1]) {
+			// Trailing delimiter is written too, it's cut below.
+			sb.WriteByte(key[i])
+		}
+	}
+
+	collapsed := sb.String()
+	if collapsed != "" && isDelimiter(collapsed[len(collapsed)-1]) {
+		collapsed = collapsed[:len(collapsed)-1]
+	}
+
+	return collapsed
+}
+
+/*
+lookupInherited is the same as lookup() but if there is no language phrase
+for the requested key in the current Locale, it continues searching
+in the parent Locale the current one inherits, if any.
+
+Client.load() guarantees there is no inheritance cycles,
+so there is no infinity loop.
+*/
+func (l *Locale) lookupInherited(key string) (string, []phraseToken, _SpecialTranslationClass) {
+
+	translatedPhrase, tokens, class := l.lookup(key)
+	isNode := class == _SPTR_TRANSLATION_KEY_IS_NODE
+
+	for loc := l; (class == _SPTR_TRANSLATION_NOT_FOUND || class == _SPTR_TRANSLATION_KEY_IS_NODE) &&
+		loc.inherits != ""; {
+
+		if loc = loc.owner.getLocale(loc.inherits); loc == nil {
+			break
+		}
+		translatedPhrase, tokens, class = loc.lookup(key)
+		isNode = isNode || class == _SPTR_TRANSLATION_KEY_IS_NODE
+	}
+
+	// The key is a node in some Locale and is not found in the others.
+	if class == _SPTR_TRANSLATION_NOT_FOUND && isNode {
+		class = _SPTR_TRANSLATION_KEY_IS_NODE
+	}
+
+	return translatedPhrase, tokens, class
+}
+
+/*
+lookupPlural is the same as lookupInherited() but treats the key
+as a node that contains language phrases for plural categories
+(see _PLURAL_ONE, _PLURAL_FEW, etc), and returns a phrase for a category
+that is selected for n by the current Locale's language plural rules.
+
+Falls back to the _PLURAL_OTHER category phrase, if there is no phrase
+for the selected one, and then to the phrase the key points to, if it's not a node.
+*/
+func (l *Locale) lookupPlural(key string, n int64) (string, []phraseToken, _SpecialTranslationClass) {
+
+	if key == "" {
+		return "", nil, _SPTR_TRANSLATION_KEY_IS_EMPTY
+	}
+
+	category := pluralCategory(l.name, n)
+	delimiter := string(l.owner.getKeyDelimiters()[0])
+
+	translatedPhrase, tokens, class := l.lookupInherited(key + delimiter + category)
+
+	if class == _SPTR_TRANSLATION_NOT_FOUND && category != _PLURAL_OTHER {
+		translatedPhrase, tokens, class = l.lookupInherited(key + delimiter + _PLURAL_OTHER)
+	}
+
+	if class == _SPTR_TRANSLATION_NOT_FOUND {
+		translatedPhrase, tokens, class = l.lookupInherited(key)
+	}
+
+	return translatedPhrase, tokens, class
+}
+
+/*
+lookupGender calls lookup for the key of the phrase of the passed gender
+(e.g: "Invited/female" for "Invited" and "female") and returns its result,
+if it's found. Otherwise the phrases of the genders from genderFallbacks
+are tried in order, and then the key itself.
+*/
+func (l *Locale) lookupGender(
+
+	key, gender string,
+	lookup      func(loc *Locale, key string) (string, []phraseToken, _SpecialTranslationClass),
+
+) (string, []phraseToken, _SpecialTranslationClass) {
+
+	if key == "" {
+		return "", nil, _SPTR_TRANSLATION_KEY_IS_EMPTY
+	}
+
+	delimiter := string(l.owner.getKeyDelimiters()[0])
+
+	if translatedPhrase, tokens, class := lookup(l, key + delimiter + gender); class == "" {
+		return translatedPhrase, tokens, ""
+	}
+
+	for _, fallbackGender := range genderFallbacks {
+		if fallbackGender == gender {
+			continue
+		}
+		if translatedPhrase, tokens, class := lookup(l, key + delimiter + fallbackGender); class == "" {
+			return translatedPhrase, tokens, ""
+		}
+	}
+
+	return lookup(l, key)
+}
+
+/*
+lookupCounted is the same as lookupInherited() but if args has an integer
+_PLURAL_COUNT_ARG argument (see icuNumber()), it's the same as lookupPlural()
+for that number. If args has a _GENDER_ARG argument (see genderName()),
+the phrase of that gender is looked up first, see lookupGender().
+The fallback locales are consulted too, see lookupFallback().
+*/
+func (l *Locale) lookupCounted(key string, args Args) (string, []phraseToken, _SpecialTranslationClass) {
+
+	lookup := func(loc *Locale, key string) (string, []phraseToken, _SpecialTranslationClass) {
+		return loc.lookupInherited(key)
+	}
+
+	if count, found := args[_PLURAL_COUNT_ARG]; found {
+		if n, isNumber := icuNumber(count); isNumber {
+			lookup = func(loc *Locale, key string) (string, []phraseToken, _SpecialTranslationClass) {
+				return loc.lookupPlural(key, n)
+			}
+		}
+	}
+
+	if gender := genderName(args[_GENDER_ARG]); gender != "" {
+		lookupForm := lookup
+		lookup = func(loc *Locale, key string) (string, []phraseToken, _SpecialTranslationClass) {
+			return loc.lookupGender(key, gender, lookupForm)
+		}
+	}
+
+	return l.lookupFallback(func(loc *Locale) (string, []phraseToken, _SpecialTranslationClass) {
+		return lookup(loc, key)
+	})
+}
+
+/*
+lookupFallback calls lookup for the current Locale and, if the language phrase
+is not found (or the key points to the node), for each of its fallback Locales
+(see Client.SetFallback(), Client.SetFallbackToDefault(), metadata's "fallback" field)
+until it's found.
+The result for the current Locale is returned, if it's found in none of them.
+*/
+func (l *Locale) lookupFallback(
+
+	lookup func(loc *Locale) (string, []phraseToken, _SpecialTranslationClass),
+
+) (string, []phraseToken, _SpecialTranslationClass) {
+
+	translatedPhrase, tokens, class := lookup(l)
+	if class != _SPTR_TRANSLATION_NOT_FOUND && class != _SPTR_TRANSLATION_KEY_IS_NODE {
+		return translatedPhrase, tokens, class
+	}
+
+	for _, fallbackLocale := range l.owner.getFallbackLocales(l) {
+		if fallbackPhrase, fallbackTokens, fallbackClass := lookup(fallbackLocale); fallbackClass == "" {
+			return fallbackPhrase, fallbackTokens, ""
+		}
+	}
+
+	return translatedPhrase, tokens, class
+}
+
+/*
+lookupVariant is the same as lookupInherited() but looks up the phrase
+of the passed variant first (e.g: "Title/__web__" for "Title" and "web"),
+falling back to the phrase the key points to, if there is no variant's one,
+and then to the _VARIANT_DEFAULT variant's phrase.
+*/
+func (l *Locale) lookupVariant(key, variant string) (string, []phraseToken, _SpecialTranslationClass) {
+
+	if key == "" {
+		return "", nil, _SPTR_TRANSLATION_KEY_IS_EMPTY
+	}
+
+	delimiter := string(l.owner.getKeyDelimiters()[0])
+	variantKey := func(variant string) string {
+		return key + delimiter + "__" + variant + "__"
+	}
+
+	if variant != "" {
+		if translatedPhrase, tokens, class := l.lookupInherited(variantKey(variant)); class == "" {
+			return translatedPhrase, tokens, ""
+		}
+	}
+
+	translatedPhrase, tokens, class := l.lookupInherited(key)
+	if class == _SPTR_TRANSLATION_NOT_FOUND || class == _SPTR_TRANSLATION_KEY_IS_NODE {
+		if defaultPhrase, defaultTokens, defaultClass := l.lookupInherited(variantKey(_VARIANT_DEFAULT)); defaultClass == "" {
+			return defaultPhrase, defaultTokens, ""
+		}
+	}
+
+	return translatedPhrase, tokens, class
+}
+
+/*
+trMissing returns a string Locale.Tr() should return
+if there is no language phrase for the requested originalKey.
+
+It's the result of MissingKeyHandler, if it's set (see Client.SetMissingKeyHandler()).
+Otherwise it's either _SPTR_TRANSLATION_NOT_FOUND special string or, if it's enabled,
+the last segment of originalKey
+(humanized if Config.MissingKeyHumanize is enabled, see humanizeKey()).
+OnMissing callback is called, if it's set.
+*/
+func (l *Locale) trMissing(originalKey string) string {
+
+	if onMissing := (*OnMissing)(atomic.LoadPointer(&l.owner.onMissing)); onMissing != nil {
+		(*onMissing)(l.name, originalKey)
+	}
+
+	if handler := (*MissingKeyHandler)(atomic.LoadPointer(&l.owner.missingKeyHandler)); handler != nil {
+		return (*handler)(l.name, originalKey)
+	}
+
+	leaf := originalKey[strings.LastIndexAny(originalKey, l.owner.getKeyDelimiters())+1:]
+
+	if atomic.LoadUint32(&l.owner.config.MissingKeyHumanize) == 1 {
+		if humanized := humanizeKey(leaf); humanized != "" {
+			return humanized
+		}
+	}
+
+	if atomic.LoadUint32(&l.owner.config.MissingKeyFallbackToLeaf) == 1 {
+		return leaf
+	}
+
+	return sptr(_SPTR_TRANSLATION_NOT_FOUND, originalKey)
+}
@@ -0,0 +1,391 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLocaleTrVariantsConsultFallbacks(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"greeting": "Hi, {{name}}",
+		"legacy":   "%d items",
+		"items":    map[string]interface{}{"one": "{{count}} item", "other": "{{count}} items"},
+		"title":    map[string]interface{}{"__mobile__": "App"},
+		"invite":   "{gender, select, female {She} other {They}} invited you",
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+	if err := c.AddLocale("de_DE", map[string]interface{}{"other": "Andere"}); err.IsNotNil() {
+		t.Fatal("failed to add de_DE")
+	}
+
+	c.SetFallback("de_DE", "en_US")
+	loc := c.LC("de_DE")
+
+	tests := []struct {
+		name       string
+		translated string
+		expected   string
+	}{
+		{"Tr", loc.Tr("greeting", Args{"name": "Bob"}), "Hi, Bob"},
+		{"TrAppend", string(loc.TrAppend(nil, "greeting", Args{"name": "Bob"})), "Hi, Bob"},
+		{"TrSafe", loc.TrSafe("greeting", Args{"name": "Bob"}), "Hi, Bob"},
+		{"TrDefault", loc.TrDefault("greeting", Args{"name": "Bob"}), "Hi, Bob"},
+		{"TrMap", loc.TrMap(map[string]Args{"greeting": {"name": "Bob"}})["greeting"], "Hi, Bob"},
+		{"Trp", loc.Trp("legacy", 3), "3 items"},
+		{"TrCount", loc.TrCount("items", 3), "3 items"},
+		{"TrVariant", loc.TrVariant("title", "mobile", nil), "App"},
+		{"TrComplex", loc.TrComplex("invite", map[string]interface{}{"gender": "female"}, nil), "She invited you"},
+		{"TrChain", c.TrChain("greeting", Args{"name": "Bob"}, "de_DE"), "Hi, Bob"},
+		{"Tr plural", loc.Tr("items", Args{"count": 1}), "1 item"},
+	}
+
+	for _, test := range tests {
+		if test.translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, test.translated, test.expected)
+		}
+	}
+}
+
+func TestLocaleTrVariantsFallbackToDefault(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"greeting": "Hi, {{name}}",
+		"items":    map[string]interface{}{"one": "{{count}} item", "other": "{{count}} items"},
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+	if err := c.AddLocale("ru_RU", map[string]interface{}{"other": "Другое"}); err.IsNotNil() {
+		t.Fatal("failed to add ru_RU")
+	}
+
+	c.LC("en_US").MarkAsDefault()
+	c.SetFallbackToDefault(true)
+	loc := c.LC("ru_RU")
+
+	tests := []struct {
+		name       string
+		translated string
+		expected   string
+	}{
+		{"Tr", loc.Tr("greeting", Args{"name": "Bob"}), "Hi, Bob"},
+		{"TrSafe", loc.TrSafe("greeting", Args{"name": "Bob"}), "Hi, Bob"},
+		{"TrMap", loc.TrMap(map[string]Args{"greeting": {"name": "Bob"}})["greeting"], "Hi, Bob"},
+		{"TrCount", loc.TrCount("items", 1), "1 item"},
+	}
+
+	for _, test := range tests {
+		if test.translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, test.translated, test.expected)
+		}
+	}
+}
+
+func TestLocaleLookupFlatKey(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"title": "Title",
+		"a/b":   "Unreachable",
+		"c/d":   "Unreachable",
+		"a":     map[string]interface{}{"b": "Nested"},
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	loc := c.LC("en_US")
+
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{"flat", "title", "Title"},
+		{"nested", "a/b", "Nested"},
+		{"delimiter in root key", "c/d", sptr(_SPTR_TRANSLATION_NOT_FOUND, "c/d")},
+	}
+
+	for _, test := range tests {
+		if translated := loc.Tr(test.key, nil); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+	}
+}
+
+func BenchmarkLocaleLookup(b *testing.B) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"title": "Title",
+		"Menu":  map[string]interface{}{"File": map[string]interface{}{"Open": "Open"}},
+	}); err.IsNotNil() {
+		b.Fatal("failed to add en_US")
+	}
+
+	loc := c.LC("en_US")
+
+	for _, key := range []string{"title", "Menu/File/Open"} {
+		b.Run(key, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _, _ = loc.lookup(key)
+			}
+		})
+	}
+}
+
+func BenchmarkLocaleTrAppend(b *testing.B) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"greeting": "Hi, {{name}}! Welcome back to {{place}}.",
+	}); err.IsNotNil() {
+		b.Fatal("failed to add en_US")
+	}
+
+	loc := c.LC("en_US")
+	args := Args{"name": "Bob", "place": "Privet"}
+
+	b.Run("Tr", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf = append(buf[:0], loc.Tr("greeting", args)...)
+		}
+	})
+
+	b.Run("TrAppend", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf = loc.TrAppend(buf[:0], "greeting", args)
+		}
+	})
+}
+
+func TestLocaleTrCollapseEmptyKeySegments(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"Menu": map[string]interface{}{"File": map[string]interface{}{"Open": "Open"}},
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	loc := c.LC("en_US")
+	incorrect := func(key string) string {
+		return sptr(_SPTR_TRANSLATION_KEY_IS_INCORRECT, key)
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		expected  string
+		collapsed string
+	}{
+		{"clean", "Menu/File/Open", "Open", "Open"},
+		{"doubled", "Menu//File/Open", incorrect("Menu//File/Open"), "Open"},
+		{"tripled", "Menu/File///Open", incorrect("Menu/File///Open"), "Open"},
+		{"leading", "/Menu/File/Open", incorrect("/Menu/File/Open"), "Open"},
+		{"trailing", "Menu/File/Open/", incorrect("Menu/File/Open/"), "Open"},
+		{"all", "//Menu//File//Open//", incorrect("//Menu//File//Open//"), "Open"},
+		{"delimiters only", "///", incorrect("///"), sptr(_SPTR_TRANSLATION_KEY_IS_EMPTY, "///")},
+	}
+
+	for _, test := range tests {
+		if translated := loc.Tr(test.key, nil); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+	}
+
+	c.SetCollapseEmptyKeySegments(true)
+
+	for _, test := range tests {
+		if translated := loc.Tr(test.key, nil); translated != test.collapsed {
+			t.Errorf("%s (collapsed): %q, expected %q", test.name, translated, test.collapsed)
+		}
+	}
+}
+
+func TestLocaleTrp(t *testing.T) {
+
+	var c Client
+
+	if err := c.AddLocale("en_US", map[string]interface{}{
+		"Cart":    map[string]interface{}{"Total": "You have %d items for %s"},
+		"percent": "100%",
+	}); err.IsNotNil() {
+		t.Fatal("failed to add en_US")
+	}
+
+	loc := c.LC("en_US")
+
+	tests := []struct {
+		name     string
+		key      string
+		args     []interface{}
+		expected string
+	}{
+		{"matched", "Cart/Total", []interface{}{3, "$12"}, "You have 3 items for $12"},
+		{"missing arg", "Cart/Total", []interface{}{3}, "You have 3 items for %!s(MISSING)"},
+		{"extra arg", "Cart/Total", []interface{}{3, "$12", true}, "You have 3 items for $12%!(EXTRA bool=true)"},
+		{"wrong type", "Cart/Total", []interface{}{"3", "$12"}, "You have %!d(string=3) items for $12"},
+		{"no args", "Cart/Total", nil, "You have %d items for %s"},
+		{"no args, lone percent", "percent", nil, "100%"},
+		{"missing key", "Cart/Sum", []interface{}{3}, sptr(_SPTR_TRANSLATION_NOT_FOUND, "Cart/Sum")},
+	}
+
+	for _, test := range tests {
+		if translated := loc.Trp(test.key, test.args...); translated != test.expected {
+			t.Errorf("%s: %q, expected %q", test.name, translated, test.expected)
+		}
+	}
+}
+
+func TestLocaleKeyDelimiterWarning(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		delimiters []byte
+		content    map[string]interface{}
+		expected   []string
+	}{
+		{
+			name:     "clean",
+			content:  map[string]interface{}{"Menu": map[string]interface{}{"Open": "Open"}},
+			expected: nil,
+		},
+		{
+			name:     "leaf key",
+			content:  map[string]interface{}{"Open/Close": "Open or close"},
+			expected: []string{"Open/Close"},
+		},
+		{
+			name:     "node name",
+			content:  map[string]interface{}{"File/Edit": map[string]interface{}{"Open": "Open"}},
+			expected: []string{"File/Edit"},
+		},
+		{
+			name:     "nested leaf key",
+			content:  map[string]interface{}{"Menu": map[string]interface{}{"a/b": "AB"}},
+			expected: []string{"Menu/a/b"},
+		},
+		{
+			name:       "custom delimiter",
+			delimiters: []byte{'.'},
+			content:    map[string]interface{}{"a/b": "AB", "c.d": "CD"},
+			expected:   []string{"c.d"},
+		},
+	}
+
+	for _, test := range tests {
+		var c Client
+		if test.delimiters != nil {
+			c.SetKeyDelimiters(test.delimiters...)
+		}
+
+		if err := c.AddLocale("en_US", test.content); err.IsNotNil() {
+			t.Fatalf("%s: failed to add en_US", test.name)
+		}
+
+		var keys []string
+		for _, warning := range c.LastLoadReport().Warnings {
+			if strings.Contains(warning.Message, "delimiter") {
+				keys = append(keys, warning.Key)
+			}
+		}
+
+		if strings.Join(keys, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: warnings for %v, expected %v", test.name, keys, test.expected)
+		}
+	}
+}
+
+func TestLocaleIgnoredKeys(t *testing.T) {
+
+	content := map[string]interface{}{
+		"title":        "Title",
+		"_draft_title": "Draft title",
+		"TODO_Footer":  "Footer",
+		"Menu": map[string]interface{}{
+			"Open":        "Open",
+			"_draft_Edit": "Edit",
+		},
+		"_draft_Menu": map[string]interface{}{"Close": "Close"},
+	}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		keys     []string
+		present  []string
+		absent   []string
+	}{
+		{
+			name:    "no prefixes",
+			keys:    []string{"TODO_Footer", "_draft_Menu/Close", "_draft_title", "Menu/Open", "Menu/_draft_Edit", "title"},
+			present: []string{"title", "_draft_title", "TODO_Footer", "Menu/_draft_Edit", "_draft_Menu/Close"},
+		},
+		{
+			name:     "draft prefix",
+			prefixes: []string{"_draft_"},
+			keys:     []string{"TODO_Footer", "Menu/Open", "title"},
+			present:  []string{"title", "TODO_Footer", "Menu/Open"},
+			absent:   []string{"_draft_title", "Menu/_draft_Edit", "_draft_Menu/Close"},
+		},
+		{
+			name:     "several prefixes",
+			prefixes: []string{"_draft_", "", "TODO_"},
+			keys:     []string{"Menu/Open", "title"},
+			present:  []string{"title", "Menu/Open"},
+			absent:   []string{"_draft_title", "TODO_Footer", "Menu/_draft_Edit", "_draft_Menu/Close"},
+		},
+	}
+
+	for _, test := range tests {
+		var c Client
+		c.SetIgnoreKeyPrefix(test.prefixes...)
+
+		if err := c.AddLocale("en_US", content); err.IsNotNil() {
+			t.Fatalf("%s: failed to add en_US", test.name)
+		}
+
+		loc := c.LC("en_US")
+
+		keys := loc.Keys()
+		sort.Strings(keys)
+		sort.Strings(test.keys)
+
+		if strings.Join(keys, ",") != strings.Join(test.keys, ",") {
+			t.Errorf("%s: keys %v, expected %v", test.name, keys, test.keys)
+		}
+		if loc.phrasesCount != uint64(len(test.keys)) {
+			t.Errorf("%s: %d phrases, expected %d", test.name, loc.phrasesCount, len(test.keys))
+		}
+		for _, key := range test.present {
+			if !loc.Has(key) {
+				t.Errorf("%s: %q is not found", test.name, key)
+			}
+		}
+		for _, key := range test.absent {
+			if translated := loc.Tr(key, nil); translated != sptr(_SPTR_TRANSLATION_NOT_FOUND, key) {
+				t.Errorf("%s: Tr(%q) = %q, expected not found", test.name, key, translated)
+			}
+		}
+	}
+}
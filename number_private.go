@@ -0,0 +1,140 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+numberGroupingSeparator returns a separator that is used to split
+groups of thousands for the language of passed locale name.
+If the language is unknown, a comma is returned (English rules).
+*/
+func numberGroupingSeparator(localeName string) string {
+	switch localeLanguage(localeName) {
+
+	case "ru", "uk", "be", "fr", "pl", "cs", "sk", "sv", "nb", "no", "fi",
+		"bg", "lt", "lv", "et", "hu", "kk":
+		return "\u00A0" // non-breaking space
+
+	case "de", "es", "it", "nl", "pt", "da", "tr", "id", "ro", "hr", "sr",
+		"sl", "el", "bs":
+		return "."
+
+	default:
+		return ","
+	}
+}
+
+/*
+numberDecimalSeparator returns a separator of the integer and fractional parts
+of number for the language of passed locale name.
+If the language is unknown, a dot is returned (English rules).
+*/
+func numberDecimalSeparator(localeName string) string {
+	if numberGroupingSeparator(localeName) == "," {
+		return "."
+	}
+	return ","
+}
+
+/*
+formatInteger returns a string representation of n with the groups of thousands
+split by the separator of the language of passed locale name
+(e.g: "1,000,000" for "en_US", "1 000 000" for "ru_RU").
+*/
+func formatInteger(localeName string, n int64) string {
+	return groupDigits(localeName, strconv.FormatInt(n, 10))
+}
+
+/*
+formatNumber returns a string representation of passed value of any integer
+or float type (or a string representation of number) with the groups of thousands
+split by the separator of the language of passed locale name and with
+the language's decimal separator (e.g: "1,234.5" for "en_US", "1 234,5" for "ru_RU").
+The 2nd returned value is false if value is not a number.
+*/
+func formatNumber(localeName string, value interface{}) (string, bool) {
+
+	var digits string
+
+	switch n := value.(type) {
+	case uint:
+		digits = strconv.FormatUint(uint64(n), 10)
+	case uint64:
+		digits = strconv.FormatUint(n, 10)
+	case float32:
+		digits = strconv.FormatFloat(float64(n), 'f', -1, 32)
+	case float64:
+		digits = strconv.FormatFloat(n, 'f', -1, 64)
+	case string:
+		// Integers are parsed as is, because float64 loses precision
+		// of the ones that are greater than 2^53.
+		n = strings.TrimSpace(n)
+		if i64, err := strconv.ParseInt(n, 10, 64); err == nil {
+			digits = strconv.FormatInt(i64, 10)
+			break
+		}
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return "", false
+		}
+		digits = strconv.FormatFloat(f, 'f', -1, 64)
+	default:
+		i64, isInteger := icuNumber(value)
+		if !isInteger {
+			return "", false
+		}
+		digits = strconv.FormatInt(i64, 10)
+	}
+
+	fraction := ""
+	if idx := strings.IndexByte(digits, '.'); idx != -1 {
+		digits, fraction = digits[:idx], numberDecimalSeparator(localeName) + digits[idx+1:]
+	}
+
+	return groupDigits(localeName, digits) + fraction, true
+}
+
+/*
+groupDigits returns passed decimal digits (optionally prefixed by "-")
+with the groups of thousands split by the separator of the language
+of passed locale name.
+*/
+func groupDigits(localeName, digits string) string {
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+
+	var (
+		sep = numberGroupingSeparator(localeName)
+		b   strings.Builder
+	)
+
+	b.Grow(len(sign) + len(digits) + (len(digits)/3)*len(sep))
+	b.WriteString(sign)
+
+	head := len(digits) % 3
+	if head == 0 {
+		head = 3
+	}
+
+	b.WriteString(digits[:head])
+	for i := head; i < len(digits); i += 3 {
+		b.WriteString(sep)
+		b.WriteString(digits[i:i+3])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,39 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"testing"
+)
+
+func TestFormatNumber(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		localeName string
+		value      interface{}
+		expected   string
+		isNumber   bool
+	}{
+		{"int en", "en_US", 1000000, "1,000,000", true},
+		{"int ru", "ru_RU", -1000000, "-1\u00A0000\u00A0000", true},
+		{"float de", "de_DE", 1234.5, "1.234,5", true},
+		{"string int", "en_US", " 1234 ", "1,234", true},
+		{"string int above 2^53", "en_US", "9007199254740993", "9,007,199,254,740,993", true},
+		{"string negative int", "en_US", "-9007199254740993", "-9,007,199,254,740,993", true},
+		{"string float", "ru_RU", "1234.25", "1\u00A0234,25", true},
+		{"string not a number", "en_US", "12a", "", false},
+		{"not a number", "en_US", true, "", false},
+	}
+
+	for _, test := range tests {
+		formatted, isNumber := formatNumber(test.localeName, test.value)
+		if formatted != test.expected || isNumber != test.isNumber {
+			t.Errorf("%s: %q, %t, expected %q, %t",
+				test.name, formatted, isNumber, test.expected, test.isNumber)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strings"
+)
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	/*
+	There are CLDR plural categories.
+	A translation key may point to the node that contains language phrases
+	for some of these categories as its keys, like:
+
+	        Cart:
+	          Items:
+	            one: "{{count}} item"
+	            other: "{{count}} items"
+
+	_PLURAL_OTHER is mandatory for all languages and is used as a fallback,
+	if the phrase for the selected category is missing.
+	*/
+	_PLURAL_ZERO  = "zero"
+	_PLURAL_ONE   = "one"
+	_PLURAL_TWO   = "two"
+	_PLURAL_FEW   = "few"
+	_PLURAL_MANY  = "many"
+	_PLURAL_OTHER = "other"
+
+	/*
+	_PLURAL_COUNT_ARG is a name of argument a pluralized count is passed by
+	to the interpolator.
+	*/
+	_PLURAL_COUNT_ARG = "count"
+)
+
+/*
+localeLanguage returns the language part of the locale name
+(e.g: "en" for "en_US").
+*/
+func localeLanguage(localeName string) string {
+	if idx := strings.IndexByte(localeName, '_'); idx != -1 {
+		return localeName[:idx]
+	}
+	return localeName
+}
+
+/*
+pluralCategory returns the CLDR plural category for the integer n
+according with the plural rules of the language of passed locale name.
+
+Only integer rules are supported. If the language is unknown,
+English rules are used ("one" for 1, "other" for the rest).
+*/
+func pluralCategory(localeName string, n int64) string {
+
+	if n < 0 {
+		n = -n
+	}
+
+	var (
+		mod10  = n % 10
+		mod100 = n % 100
+	)
+
+	switch localeLanguage(localeName) {
+
+	case "ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km":
+		return _PLURAL_OTHER
+
+	case "fr", "hy", "kab":
+		if n == 0 || n == 1 {
+			return _PLURAL_ONE
+		}
+		return _PLURAL_OTHER
+
+	case "ru", "uk", "be":
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return _PLURAL_ONE
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return _PLURAL_FEW
+		default:
+			return _PLURAL_MANY
+		}
+
+	case "pl":
+		switch {
+		case n == 1:
+			return _PLURAL_ONE
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return _PLURAL_FEW
+		default:
+			return _PLURAL_MANY
+		}
+
+	case "cs", "sk":
+		switch {
+		case n == 1:
+			return _PLURAL_ONE
+		case n >= 2 && n <= 4:
+			return _PLURAL_FEW
+		default:
+			return _PLURAL_OTHER
+		}
+
+	case "sr", "hr", "bs":
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return _PLURAL_ONE
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return _PLURAL_FEW
+		default:
+			return _PLURAL_OTHER
+		}
+
+	case "lt":
+		switch {
+		case mod10 == 1 && (mod100 < 11 || mod100 > 19):
+			return _PLURAL_ONE
+		case mod10 >= 2 && (mod100 < 11 || mod100 > 19):
+			return _PLURAL_FEW
+		default:
+			return _PLURAL_OTHER
+		}
+
+	case "lv":
+		switch {
+		case mod10 == 0 || (mod100 >= 11 && mod100 <= 19):
+			return _PLURAL_ZERO
+		case mod10 == 1 && mod100 != 11:
+			return _PLURAL_ONE
+		default:
+			return _PLURAL_OTHER
+		}
+
+	case "ro":
+		switch {
+		case n == 1:
+			return _PLURAL_ONE
+		case n == 0 || (mod100 >= 1 && mod100 <= 19):
+			return _PLURAL_FEW
+		default:
+			return _PLURAL_OTHER
+		}
+
+	case "he":
+		switch n {
+		case 1:
+			return _PLURAL_ONE
+		case 2:
+			return _PLURAL_TWO
+		default:
+			return _PLURAL_OTHER
+		}
+
+	case "ar":
+		switch {
+		case n == 0:
+			return _PLURAL_ZERO
+		case n == 1:
+			return _PLURAL_ONE
+		case n == 2:
+			return _PLURAL_TWO
+		case mod100 >= 3 && mod100 <= 10:
+			return _PLURAL_FEW
+		case mod100 >= 11:
+			return _PLURAL_MANY
+		default:
+			return _PLURAL_OTHER
+		}
+
+	default:
+		if n == 1 {
+			return _PLURAL_ONE
+		}
+		return _PLURAL_OTHER
+	}
+}
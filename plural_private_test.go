@@ -0,0 +1,36 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"testing"
+)
+
+func TestPluralCategory(t *testing.T) {
+
+	tests := []struct {
+		localeName string
+		n          int64
+		expected   string
+	}{
+		{"ro_RO", 0, _PLURAL_FEW},
+		{"ro_RO", 1, _PLURAL_ONE},
+		{"ro_RO", 2, _PLURAL_FEW},
+		{"ro_RO", 19, _PLURAL_FEW},
+		{"ro_RO", 20, _PLURAL_OTHER},
+		{"ro_RO", 100, _PLURAL_OTHER},
+		{"ro_RO", 101, _PLURAL_FEW},
+		{"ro_RO", 119, _PLURAL_FEW},
+		{"ro_RO", 120, _PLURAL_OTHER},
+	}
+
+	for _, test := range tests {
+		if category := pluralCategory(test.localeName, test.n); category != test.expected {
+			t.Errorf("pluralCategory(%q, %d) = %q, expected %q",
+				test.localeName, test.n, category, test.expected)
+		}
+	}
+}
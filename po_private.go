@@ -0,0 +1,695 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	/*
+	poEntry is one message of gettext PO file under decoding.
+	See poUnmarshal().
+	*/
+	poEntry struct {
+		line     int      // line number the entry starts at, for errors only
+		context  string   // msgctxt
+		id       string   // msgid
+		idPlural string   // msgid_plural
+		str      []string // msgstr or msgstr[n] by n
+		hasStr   bool     // whether msgstr or msgstr[n] is found
+		isPlural bool     // whether msgid_plural is found
+		isFuzzy  bool     // whether "#, fuzzy" flag is found
+		current  *string  // the field the continuation lines are appended to
+	}
+
+	/*
+	poPluralForms is a decoded plural message of PO file (msgstr[n] by n),
+	that is replaced by the node of the CLDR plural categories,
+	as soon as the locale name is known. See poResolvePlurals().
+	*/
+	poPluralForms []string
+
+	/*
+	poHeader is the fields of PO header (msgstr of the message with empty msgid)
+	privet uses. See poUnmarshal().
+	*/
+	poHeader struct {
+		language    string // "Language" field, e.g: "sr@latin"
+		pluralForms string // "Plural-Forms" field, e.g: "nplurals=2; plural=(n != 1);"
+	}
+
+	/*
+	poPluralParser parses the "plural" expression of "Plural-Forms" field
+	of PO header, that is a C expression of n. See poParsePluralForms().
+	*/
+	poPluralParser struct {
+		expr string
+		pos  int
+	}
+
+	/*
+	poPluralExpr is a parsed expression (or its part) of "Plural-Forms" field,
+	that returns the index of msgstr[n] for n.
+	*/
+	poPluralExpr func(n int64) int64
+)
+
+var (
+	/*
+	poCharsetRegexp is used to find the charset declaration of PO file
+	in its header before decoding (e.g: "Content-Type: text/plain; charset=UTF-8").
+	See SourceItem.transcode().
+	*/
+	poCharsetRegexp = regexp.MustCompile(`(?i)content-type:[^"\n]*charset=([A-Za-z0-9_.:-]+)`)
+
+	/*
+	pluralCategoriesOrder is the order of CLDR plural categories
+	msgstr[n] of PO file are mapped to. See poResolvePlurals().
+	*/
+	pluralCategoriesOrder = [...]string{
+		_PLURAL_ZERO, _PLURAL_ONE, _PLURAL_TWO, _PLURAL_FEW, _PLURAL_MANY, _PLURAL_OTHER,
+	}
+
+	/*
+	poPluralFormsRegexp is used to split "Plural-Forms" field of PO header
+	into the number of forms and the expression. See poParsePluralForms().
+	*/
+	poPluralFormsRegexp = regexp.MustCompile(`^\s*nplurals\s*=\s*(\d+)\s*;\s*plural\s*=\s*([^;]+);?\s*$`)
+
+	/*
+	poScriptModifiers maps the modifiers of PO header's language
+	(e.g: "latin" for "sr@latin") to the script subtags they mean.
+	See poLocaleName().
+	*/
+	poScriptModifiers = map[string]string{
+		"latin":      "Latn",
+		"cyrillic":   "Cyrl",
+		"arabic":     "Arab",
+		"devanagari": "Deva",
+	}
+)
+
+/*
+poUnmarshal decodes gettext PO file's content into the tree of language phrases,
+the same as other decoders do, and returns the fields of PO header it has.
+
+The key of each message is its msgid prefixed by its msgctxt, if any,
+and split into the nested nodes by any of passed key delimiters
+(see Client.SetKeyDelimiters()), e.g: msgctxt "Menu" with msgid "File/Open"
+is "Menu/File/Open", so the message is reachable by Tr() using exactly the same key.
+
+The plural messages (msgid_plural with msgstr[n]) are saved as poPluralForms,
+see poResolvePlurals(). Fuzzy, obsolete and not translated messages are skipped.
+*/
+func poUnmarshal(content []byte, delimiters string) (map[string]interface{}, poHeader, error) {
+
+	var (
+		root    = make(map[string]interface{})
+		header  poHeader
+		entry   poEntry
+		lineNum int
+	)
+
+	flush := func() error {
+		defer func() { entry = poEntry{} }()
+
+		switch {
+		case !entry.hasStr:
+			if entry.current != nil {
+				return fmt.Errorf("line %d: message has no msgstr", entry.line)
+			}
+			return nil
+
+		case entry.id == "" && entry.context == "":
+			header.language = poHeaderField(strings.Join(entry.str, ""), "Language")
+			header.pluralForms = poHeaderField(strings.Join(entry.str, ""), "Plural-Forms")
+			return nil
+
+		case entry.isFuzzy:
+			return nil
+		}
+
+		var value interface{}
+		if entry.isPlural {
+			translated := false
+			for _, form := range entry.str {
+				translated = translated || form != ""
+			}
+			if !translated {
+				return nil
+			}
+			value = poPluralForms(entry.str)
+		} else {
+			if len(entry.str) == 0 || entry.str[0] == "" {
+				return nil
+			}
+			value = entry.str[0]
+		}
+
+		key := entry.id
+		if entry.context != "" {
+			key = entry.context + delimiters[:1] + key
+		}
+
+		return poInsert(root, key, delimiters, value, entry.line)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, len(content) + 1)
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		var (
+			keyword = line
+			value   string
+		)
+		if idx := strings.IndexByte(line, ' '); idx != -1 && line[0] != '"' {
+			keyword, value = line[:idx], strings.TrimSpace(line[idx+1:])
+		}
+
+		switch {
+
+		case line == "":
+			if err := flush(); err != nil {
+				return nil, poHeader{}, err
+			}
+			continue
+
+		case strings.HasPrefix(line, "#"):
+			if entry.hasStr {
+				if err := flush(); err != nil {
+					return nil, poHeader{}, err
+				}
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.isFuzzy = true
+			}
+			// Obsolete messages ("#~") are comments too.
+			continue
+
+		case line[0] == '"':
+			if entry.current == nil {
+				return nil, poHeader{}, fmt.Errorf("line %d: string w/o keyword", lineNum)
+			}
+			value = line
+
+		case keyword == "msgctxt" || keyword == "msgid":
+			// The message is over, if either it has msgstr already
+			// or the same (or preceding) field is found again.
+			if entry.hasStr || entry.current != nil &&
+				(keyword == "msgctxt" || entry.current != &entry.context) {
+
+				if err := flush(); err != nil {
+					return nil, poHeader{}, err
+				}
+			}
+			if entry.line == 0 {
+				entry.line = lineNum
+			}
+			if keyword == "msgctxt" {
+				entry.current = &entry.context
+			} else {
+				entry.current = &entry.id
+			}
+
+		case keyword == "msgid_plural":
+			entry.isPlural = true
+			entry.current = &entry.idPlural
+
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			if entry.line == 0 {
+				return nil, poHeader{}, fmt.Errorf("line %d: msgstr w/o msgid", lineNum)
+			}
+			idx := 0
+			if keyword != "msgstr" {
+				n, legacyErr := strconv.Atoi(strings.TrimSuffix(keyword[len("msgstr["):], "]"))
+				if legacyErr != nil || n < 0 || !strings.HasSuffix(keyword, "]") {
+					return nil, poHeader{}, fmt.Errorf("line %d: malformed plural form index", lineNum)
+				}
+				idx = n
+			}
+			for len(entry.str) <= idx {
+				entry.str = append(entry.str, "")
+			}
+			entry.hasStr = true
+			entry.current = &entry.str[idx]
+
+		default:
+			return nil, poHeader{}, fmt.Errorf("line %d: unknown keyword %q", lineNum, keyword)
+		}
+
+		str, legacyErr := strconv.Unquote(value)
+		if legacyErr != nil {
+			return nil, poHeader{}, fmt.Errorf("line %d: malformed string: %s", lineNum, legacyErr.Error())
+		}
+		*entry.current += str
+	}
+
+	if legacyErr := scanner.Err(); legacyErr != nil {
+		return nil, poHeader{}, legacyErr
+	}
+	if err := flush(); err != nil {
+		return nil, poHeader{}, err
+	}
+
+	return root, header, nil
+}
+
+/*
+poInsert saves passed value to the root by passed key, that is split
+into the nested nodes by any of passed key delimiters,
+creating them if they are not exist.
+line is the line number of the message, for errors only.
+*/
+func poInsert(root map[string]interface{}, key, delimiters string, value interface{}, line int) error {
+
+	var names []string
+	for rest := key; ; {
+		idx := indexKeyDelimiter(rest, delimiters)
+		if idx == -1 {
+			names = append(names, rest)
+			break
+		}
+		names = append(names, rest[:idx])
+		rest = rest[idx+1:]
+	}
+
+	node := root
+
+	for _, name := range names[:len(names)-1] {
+		switch subNode := node[name].(type) {
+		case nil:
+			newSubNode := make(map[string]interface{})
+			node[name] = newSubNode
+			node = newSubNode
+		case map[string]interface{}:
+			node = subNode
+		default:
+			return fmt.Errorf("line %d: key %q is a prefix of message, but is a message too", line, name)
+		}
+	}
+
+	name := names[len(names)-1]
+	if _, isExist := node[name]; isExist {
+		return fmt.Errorf("line %d: message %q is duplicated or is a prefix of another one", line, key)
+	}
+
+	node[name] = value
+	return nil
+}
+
+/*
+poHeaderField returns the value of the field of PO header
+(msgstr of the message with empty msgid), e.g: "ru" for "Language: ru".
+Returns an empty string if there is no such field.
+*/
+func poHeaderField(header, field string) string {
+	for _, line := range strings.Split(header, "\n") {
+		if idx := strings.IndexByte(line, ':'); idx != -1 &&
+			strings.EqualFold(strings.TrimSpace(line[:idx]), field) {
+
+			return strings.TrimSpace(line[idx+1:])
+		}
+	}
+	return ""
+}
+
+/*
+poLocaleName returns the locale name of passed PO header's language
+(e.g: "pt_BR"), that might have a codeset (e.g: "pt_BR.UTF-8")
+and a modifier (e.g: "sr@latin"). Codeset is dropped. Modifier is converted
+to the script subtag if it's a script's name (e.g: "sr_Latn"), dropped otherwise.
+*/
+func poLocaleName(language string) string {
+
+	var modifier string
+	if idx := strings.IndexByte(language, '@'); idx != -1 {
+		language, modifier = language[:idx], language[idx+1:]
+	}
+	if idx := strings.IndexByte(language, '.'); idx != -1 {
+		language = language[:idx]
+	}
+
+	localeName := normalizeLocaleName(language)
+
+	if script := poScriptModifiers[strings.ToLower(modifier)]; script != "" {
+		if lang, oldScript, region := parseLocaleName(localeName); lang != "" && oldScript == "" {
+			localeName = joinLocaleName(lang, script, region)
+		}
+	}
+
+	return localeName
+}
+
+/*
+poResolvePlurals replaces each poPluralForms of the root (recursively)
+by the node of the CLDR plural categories, that is used by Locale.Tr()
+if "count" argument is passed.
+
+msgstr[n] are mapped to the plural categories the language of passed locale
+has (see pluralCategory()) by passed "Plural-Forms" field of PO header
+(see poPluralCategories()). If there is no such field, they are mapped
+in CLDR order: "zero", "one", "two", "few", "many", "other",
+and the number of forms must be the same as the number of categories.
+Empty forms are skipped.
+
+Returns an error if "Plural-Forms" field is malformed or the forms can't be mapped.
+*/
+func poResolvePlurals(root map[string]interface{}, localeName, pluralForms string) error {
+
+	var categories []string
+
+	var resolve func(root map[string]interface{}) error
+	resolve = func(root map[string]interface{}) error {
+		for key, value := range root {
+			switch typedValue := value.(type) {
+
+			case map[string]interface{}:
+				if err := resolve(typedValue); err != nil {
+					return err
+				}
+
+			case poPluralForms:
+				if categories == nil {
+					var err error
+					if categories, err = poPluralCategories(localeName, pluralForms); err != nil {
+						return err
+					}
+				}
+				if pluralForms == "" && len(typedValue) != len(categories) {
+					return fmt.Errorf("message %q has %d plural forms, but %s has %d (%s), " +
+						"declare Plural-Forms in the header", key, len(typedValue),
+						localeName, len(categories), strings.Join(categories, ", "))
+				}
+				node := make(map[string]interface{}, len(categories))
+				for i, form := range typedValue {
+					if i < len(categories) && categories[i] != "" && form != "" {
+						node[categories[i]] = form
+					}
+				}
+				root[key] = node
+			}
+		}
+		return nil
+	}
+
+	return resolve(root)
+}
+
+/*
+poPluralCategories returns the CLDR plural categories the language
+of passed locale name has (see pluralCategory()) by the index of msgstr[n].
+
+If pluralForms ("Plural-Forms" field of PO header) is empty,
+they are in CLDR order. Otherwise the expression of the field is evaluated
+for the numbers 0..999, and each form is mapped to the category the most of
+its numbers have, that is not mapped yet. The category is empty
+if there is no such one (the form is skipped then).
+*/
+func poPluralCategories(localeName, pluralForms string) ([]string, error) {
+
+	const N = 1000
+
+	if pluralForms == "" {
+		used := make(map[string]struct{}, len(pluralCategoriesOrder))
+		for n := int64(0); n < N; n++ {
+			used[pluralCategory(localeName, n)] = struct{}{}
+		}
+
+		categories := make([]string, 0, len(used))
+		for _, category := range pluralCategoriesOrder {
+			if _, isUsed := used[category]; isUsed {
+				categories = append(categories, category)
+			}
+		}
+
+		return categories, nil
+	}
+
+	nplurals, plural, err := poParsePluralForms(pluralForms)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]map[string]int, nplurals)
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+
+	for n := int64(0); n < N; n++ {
+		idx := plural(n)
+		if idx < 0 || idx >= int64(nplurals) {
+			return nil, fmt.Errorf("Plural-Forms: form %d of %d is out of nplurals %d", idx, n, nplurals)
+		}
+		counts[idx][pluralCategory(localeName, n)]++
+	}
+
+	var (
+		categories = make([]string, nplurals)
+		isMapped   = make(map[string]bool, nplurals)
+	)
+
+	for i := range categories {
+		for _, category := range pluralCategoriesOrder {
+			if !isMapped[category] && counts[i][category] > counts[i][categories[i]] {
+				categories[i] = category
+			}
+		}
+		isMapped[categories[i]] = true
+	}
+
+	return categories, nil
+}
+
+/*
+poParsePluralForms parses "Plural-Forms" field of PO header
+(e.g: "nplurals=2; plural=(n != 1);") and returns the number of forms
+and the parsed expression.
+*/
+func poParsePluralForms(pluralForms string) (int, poPluralExpr, error) {
+
+	match := poPluralFormsRegexp.FindStringSubmatch(pluralForms)
+	if match == nil {
+		return 0, nil, fmt.Errorf("Plural-Forms: malformed field %q", pluralForms)
+	}
+
+	nplurals, legacyErr := strconv.Atoi(match[1])
+	if legacyErr != nil || nplurals < 1 || nplurals > len(pluralCategoriesOrder) {
+		return 0, nil, fmt.Errorf("Plural-Forms: unexpected nplurals %q", match[1])
+	}
+
+	p := poPluralParser{expr: match[2]}
+
+	plural, err := p.parseTernary()
+	if err == nil && p.skipSpaces() < len(p.expr) {
+		err = fmt.Errorf("unexpected %q", p.expr[p.pos:])
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("Plural-Forms: malformed plural %q: %s", match[2], err.Error())
+	}
+
+	return nplurals, plural, nil
+}
+
+/*
+skipSpaces skips the spaces of the expression and returns the current position.
+*/
+func (p *poPluralParser) skipSpaces() int {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t') {
+		p.pos++
+	}
+	return p.pos
+}
+
+/*
+consume skips the spaces and passed token, if it's the next one,
+reporting whether it's so.
+*/
+func (p *poPluralParser) consume(token string) bool {
+	if strings.HasPrefix(p.expr[p.skipSpaces():], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+/*
+parseTernary parses "cond ? a : b" or the operand of lower priority.
+*/
+func (p *poPluralParser) parseTernary() (poPluralExpr, error) {
+
+	cond, err := p.parseBinary(0)
+	if err != nil || !p.consume("?") {
+		return cond, err
+	}
+
+	a, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume(":") {
+		return nil, fmt.Errorf("expected \":\" at %d", p.pos)
+	}
+	b, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(n int64) int64 {
+		if cond(n) != 0 {
+			return a(n)
+		}
+		return b(n)
+	}, nil
+}
+
+/*
+parseBinary parses the binary operators of passed priority level and higher ones
+(from "||" to "*", "/", "%"), that are left associative, like C does.
+*/
+func (p *poPluralParser) parseBinary(level int) (poPluralExpr, error) {
+
+	levels := [...][]string{
+		{"||"},
+		{"&&"},
+		{"==", "!="},
+		{"<=", ">=", "<", ">"},
+		{"+", "-"},
+		{"*", "/", "%"},
+	}
+
+	if level == len(levels) {
+		return p.parseUnary()
+	}
+
+	a, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var op string
+		for _, token := range levels[level] {
+			if p.consume(token) {
+				op = token
+				break
+			}
+		}
+		if op == "" {
+			return a, nil
+		}
+
+		b, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		a = poPluralBinary(op, a, b)
+	}
+}
+
+/*
+parseUnary parses "!x", "(x)", "n" or a number.
+*/
+func (p *poPluralParser) parseUnary() (poPluralExpr, error) {
+
+	switch {
+	case p.consume("!"):
+		a, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int64) int64 { return poPluralBool(a(n) == 0) }, nil
+
+	case p.consume("("):
+		a, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expected \")\" at %d", p.pos)
+		}
+		return a, nil
+
+	case p.consume("n"):
+		return func(n int64) int64 { return n }, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+		p.pos++
+	}
+
+	value, legacyErr := strconv.ParseInt(p.expr[start:p.pos], 10, 64)
+	if legacyErr != nil {
+		return nil, fmt.Errorf("expected a number at %d", start)
+	}
+
+	return func(int64) int64 { return value }, nil
+}
+
+/*
+poPluralBinary returns the expression of passed binary operator op for a and b.
+Division by zero is 0.
+*/
+func poPluralBinary(op string, a, b poPluralExpr) poPluralExpr {
+	switch op {
+	case "||":
+		return func(n int64) int64 { return poPluralBool(a(n) != 0 || b(n) != 0) }
+	case "&&":
+		return func(n int64) int64 { return poPluralBool(a(n) != 0 && b(n) != 0) }
+	case "==":
+		return func(n int64) int64 { return poPluralBool(a(n) == b(n)) }
+	case "!=":
+		return func(n int64) int64 { return poPluralBool(a(n) != b(n)) }
+	case "<=":
+		return func(n int64) int64 { return poPluralBool(a(n) <= b(n)) }
+	case ">=":
+		return func(n int64) int64 { return poPluralBool(a(n) >= b(n)) }
+	case "<":
+		return func(n int64) int64 { return poPluralBool(a(n) < b(n)) }
+	case ">":
+		return func(n int64) int64 { return poPluralBool(a(n) > b(n)) }
+	case "+":
+		return func(n int64) int64 { return a(n) + b(n) }
+	case "-":
+		return func(n int64) int64 { return a(n) - b(n) }
+	case "*":
+		return func(n int64) int64 { return a(n) * b(n) }
+	case "/":
+		return func(n int64) int64 {
+			if d := b(n); d != 0 {
+				return a(n) / d
+			}
+			return 0
+		}
+	default:
+		return func(n int64) int64 {
+			if d := b(n); d != 0 {
+				return a(n) % d
+			}
+			return 0
+		}
+	}
+}
+
+/*
+poPluralBool converts b to the C's boolean: 1 or 0.
+*/
+func poPluralBool(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
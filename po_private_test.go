@@ -0,0 +1,144 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPoLocaleName(t *testing.T) {
+
+	tests := []struct {
+		language string
+		expected string
+	}{
+		{"ru", "ru"},
+		{"pt_BR", "pt_BR"},
+		{"pt-br.UTF-8", "pt_BR"},
+		{"sr@latin", "sr_Latn"},
+		{"sr_RS@latin", "sr_Latn_RS"},
+		{"ca@valencia", "ca"},
+	}
+
+	for _, test := range tests {
+		if localeName := poLocaleName(test.language); localeName != test.expected {
+			t.Errorf("poLocaleName(%q) = %q, expected %q", test.language, localeName, test.expected)
+		}
+	}
+}
+
+func TestPoPluralCategories(t *testing.T) {
+
+	tests := []struct {
+		localeName  string
+		pluralForms string
+		expected    string
+		isFailed    bool
+	}{
+		{"en", "", "one,other", false},
+		{"ru", "", "one,few,many", false},
+		{"en", "nplurals=2; plural=(n != 1);", "one,other", false},
+		{"ru", "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", "one,few,many", false},
+		{"lv", "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);", "one,other,zero", false},
+		{"ja", "nplurals=1; plural=0;", "other", false},
+		{"fr", "nplurals=2; plural=n>1;", "one,other", false},
+		{"en", "nplurals=2; plural=n+;", "", true},
+		{"en", "nplurals=2; plural=(n != 1", "", true},
+		{"en", "nplurals=1; plural=(n != 1);", "", true},
+		{"en", "plural=(n != 1);", "", true},
+	}
+
+	for _, test := range tests {
+		categories, err := poPluralCategories(test.localeName, test.pluralForms)
+
+		switch {
+		case (err != nil) != test.isFailed:
+			t.Errorf("%s %q: error = %v, expected failure: %t", test.localeName, test.pluralForms, err, test.isFailed)
+		case !test.isFailed && strings.Join(categories, ",") != test.expected:
+			t.Errorf("%s %q: categories = %v, expected %s", test.localeName, test.pluralForms, categories, test.expected)
+		}
+	}
+}
+
+func TestClientLoadPo(t *testing.T) {
+
+	const content = `
+msgid ""
+msgstr ""
+"Language: lv\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);\n"
+
+msgctxt "Cart"
+msgid "Items"
+msgid_plural "Items"
+msgstr[0] "{{count}} prece"
+msgstr[1] "{{count}} preces"
+msgstr[2] "nav preču"
+`
+
+	var c Client
+	c.SetKeyDelimiters('.')
+
+	if err := c.Source(RawSource{Format: "po", Data: []byte(content)}); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+
+	for n, expected := range map[int]string{1: "1 prece", 2: "2 preces", 0: "nav preču"} {
+		if translated := c.Tr("lv", "Cart.Items", Args{"count": n}); translated != expected {
+			t.Errorf("Tr(%d) = %q, expected %q", n, translated, expected)
+		}
+	}
+}
+
+func TestClientLoadPoWithModifier(t *testing.T) {
+
+	const content = `
+msgid ""
+msgstr ""
+"Language: sr@latin\n"
+
+msgid "Hello"
+msgstr "Zdravo"
+`
+
+	var c Client
+	if err := c.Source(RawSource{Format: "po", Data: []byte(content)}); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNotNil() {
+		t.Fatal("failed to load")
+	}
+
+	if translated := c.Tr("sr_Latn", "Hello", nil); translated != "Zdravo" {
+		t.Errorf("Tr() = %q, expected %q", translated, "Zdravo")
+	}
+}
+
+func TestClientLoadPoPluralFormsMismatch(t *testing.T) {
+
+	const content = `
+msgid ""
+msgstr ""
+"Language: ru\n"
+
+msgid "Items"
+msgid_plural "Items"
+msgstr[0] "{{count}} товар"
+msgstr[1] "{{count}} товаров"
+`
+
+	var c Client
+	if err := c.Source(RawSource{Format: "po", Data: []byte(content)}); err.IsNotNil() {
+		t.Fatal("failed to source")
+	}
+	if err := c.Load(); err.IsNil() {
+		t.Error("Load() = nil, expected an error of plural forms mismatch")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strings"
+)
+
+type (
+	/*
+	RawSource is a RAW data (content) of locale's file, that might be passed
+	to Client.Source() with its format and locale name stated explicitly,
+	so neither the format is detected by trying decoders one by one
+	(see Client.SetContentResolveOrder()), nor the metadata is required:
+
+	        privet.Source(privet.RawSource{
+	            Format: "yaml",
+	            Data:   []byte("Main:\n  Greetings: Hello, {{name}}!"),
+	            Name:   "en_US",
+	        })
+	*/
+	RawSource struct {
+
+		/*
+		Format is a format of Data: "yaml" (or "yml"), "toml", "json" or "po",
+		case insensitive. Empty means it's unknown and must be detected,
+		the same way as for []byte source.
+		*/
+		Format string
+
+		/*
+		Data is the content of locale's file.
+		*/
+		Data []byte
+
+		/*
+		Name is a locale name of Data (e.g: "en_US").
+		Empty means it's declared by the metadata of Data.
+		It's an error, if it's declared by both.
+		*/
+		Name string
+	}
+)
+
+/*
+sourceItemType returns a type of SourceItem for the current RawSource's format.
+The 2nd returned value is false if format is not supported.
+*/
+func (rs *RawSource) sourceItemType() (SourceItemType, bool) {
+	switch strings.ToLower(strings.TrimSpace(rs.Format)) {
+	case "":
+		return SOURCE_ITEM_TYPE_CONTENT_UNKNOWN, true
+	case "yml", "yaml":
+		return SOURCE_ITEM_TYPE_CONTENT_YAML, true
+	case "toml":
+		return SOURCE_ITEM_TYPE_CONTENT_TOML, true
+	case "json":
+		return SOURCE_ITEM_TYPE_CONTENT_JSON, true
+	case "po":
+		return SOURCE_ITEM_TYPE_CONTENT_PO, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strings"
+	"time"
+)
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	/*
+	_RELATIVE_NODE is a name of the root's node that contains templates
+	of relative time phrases for each time unit and plural category,
+	for the past, and for the future inside _RELATIVE_FUTURE node, like:
+
+	        __relative__:
+	          minutes:
+	            one: "{{count}} minute ago"
+	            other: "{{count}} minutes ago"
+	          future:
+	            minutes:
+	              one: "in {{count}} minute"
+	              other: "in {{count}} minutes"
+
+	See Locale.TrRelative() for more details.
+	*/
+	_RELATIVE_NODE   = "__relative__"
+	_RELATIVE_FUTURE = "future"
+)
+
+var (
+	/*
+	relativeTimeUnits are the time units of relative time phrases
+	from the biggest one to the smallest one.
+	*/
+	relativeTimeUnits = []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"years", 365 * 24 * time.Hour},
+		{"months", 30 * 24 * time.Hour},
+		{"days", 24 * time.Hour},
+		{"hours", time.Hour},
+		{"minutes", time.Minute},
+		{"seconds", time.Second},
+	}
+)
+
+/*
+relativeTimeUnit returns the name of the biggest time unit (see relativeTimeUnits)
+that fits into the absolute value of d, and the number of these units in d.
+Seconds are used for durations less than second.
+*/
+func relativeTimeUnit(d time.Duration) (string, int64) {
+
+	if d < 0 {
+		d = -d
+	}
+
+	for _, unit := range relativeTimeUnits {
+		if d >= unit.duration {
+			return unit.name, int64(d / unit.duration)
+		}
+	}
+
+	return "seconds", 0
+}
+
+/*
+relativeTimeKey returns the translation key of the relative time phrase
+of passed time unit (see _RELATIVE_NODE), joined by passed delimiter.
+*/
+func relativeTimeKey(unit string, isFuture bool, delimiter byte) string {
+
+	prefix := _RELATIVE_NODE + string(delimiter)
+
+	if isFuture {
+		return prefix + _RELATIVE_FUTURE + string(delimiter) + unit
+	}
+	return prefix + unit
+}
+
+/*
+relativeTimeDefault returns the English template of the relative time phrase
+of passed time unit for n, that is used if Locale has no its own one
+(e.g: "{{count}} minutes ago" or "in {{count}} minute").
+*/
+func relativeTimeDefault(unit string, n int64, isFuture bool) string {
+
+	if pluralCategory("en", n) == _PLURAL_ONE {
+		unit = strings.TrimSuffix(unit, "s")
+	}
+
+	if isFuture {
+		return "in {{" + _PLURAL_COUNT_ARG + "}} " + unit
+	}
+	return "{{" + _PLURAL_COUNT_ARG + "}} " + unit + " ago"
+}
@@ -5,6 +5,11 @@
 
 package privet
 
+import (
+	"io/fs"
+	"time"
+)
+
 type (
 	/*
 	SourceItem is a type that represents one thing that will be used as a source
@@ -24,11 +29,21 @@ type (
 	SourceItem doesn't mean that source it holds is valid.
 	*/
 	SourceItem struct {
-		Type       SourceItemType
-		Path       string
-		LocaleName string
-		content    []byte
-		md5        string
+		Type         SourceItemType
+		Path         string
+		LocaleName   string
+		sourcedName  string                 // locale name passed to Source(), may be empty
+		content      []byte
+		md5          string
+		inherits     string                 // parent locale name from metadata, may be empty
+		fallbacks    []string               // fallback locale names from metadata, may be empty
+		listFormat   listFormat             // list formatting rules from metadata, may be empty
+		requiredArgs map[string][]string    // required args by translation key from metadata
+		modTime      time.Time              // last modification time of file, zero for content
+		keyOrder     map[string][]string    // declared keys order by node's key, only while loading
+		tree         map[string]interface{} // decoded content of flat map or map
+		opts         SourceOptions          // options of SourceWithOptions() call
+		fsys         fs.FS                  // file system the file is taken from, nil for OS's one
 	}
 
 	/*
@@ -47,7 +62,46 @@ const (
 	*/
 	SOURCE_ITEM_TYPE_FILE_YAML       SourceItemType = 100
 	SOURCE_ITEM_TYPE_FILE_TOML       SourceItemType = 101
+	SOURCE_ITEM_TYPE_FILE_JSON       SourceItemType = 102
+	SOURCE_ITEM_TYPE_FILE_PO         SourceItemType = 103
 	SOURCE_ITEM_TYPE_CONTENT_UNKNOWN SourceItemType = 150
 	SOURCE_ITEM_TYPE_CONTENT_YAML    SourceItemType = 151
-	SOURCE_ITEM_TYPE_CONTENT_TOML    SourceItemType = 151
+	SOURCE_ITEM_TYPE_CONTENT_TOML    SourceItemType = 152
+	SOURCE_ITEM_TYPE_CONTENT_FLAT    SourceItemType = 153
+	SOURCE_ITEM_TYPE_CONTENT_MAP     SourceItemType = 154
+	SOURCE_ITEM_TYPE_CONTENT_JSON    SourceItemType = 155
+	SOURCE_ITEM_TYPE_CONTENT_PO      SourceItemType = 156
 )
+
+/*
+String returns a human readable name of the current SourceItemType,
+like "YAML file" or "TOML content".
+*/
+func (t SourceItemType) String() string {
+	switch t {
+	case SOURCE_ITEM_TYPE_FILE_YAML:
+		return "YAML file"
+	case SOURCE_ITEM_TYPE_FILE_TOML:
+		return "TOML file"
+	case SOURCE_ITEM_TYPE_FILE_JSON:
+		return "JSON file"
+	case SOURCE_ITEM_TYPE_FILE_PO:
+		return "PO file"
+	case SOURCE_ITEM_TYPE_CONTENT_UNKNOWN:
+		return "unknown content"
+	case SOURCE_ITEM_TYPE_CONTENT_YAML:
+		return "YAML content"
+	case SOURCE_ITEM_TYPE_CONTENT_FLAT:
+		return "flat map"
+	case SOURCE_ITEM_TYPE_CONTENT_MAP:
+		return "map"
+	case SOURCE_ITEM_TYPE_CONTENT_TOML:
+		return "TOML content"
+	case SOURCE_ITEM_TYPE_CONTENT_JSON:
+		return "JSON content"
+	case SOURCE_ITEM_TYPE_CONTENT_PO:
+		return "PO content"
+	default:
+		return "<unknown>"
+	}
+}
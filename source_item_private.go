@@ -6,7 +6,10 @@
 package privet
 
 import (
+	"bytes"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"unsafe"
 
@@ -14,24 +17,57 @@ import (
 	"github.com/qioalice/ekago/v2/ekaunsafe"
 
 	"github.com/modern-go/reflect2"
+
+	"golang.org/x/text/encoding/htmlindex"
 )
 
 var (
 	rtypeArrMapStringInterface = reflect2.RTypeOf([]map[string]interface{}(nil))
+
+	/*
+	sourceMetaDataRegexp is used to find the beginning of source's metadata section
+	before decoding: YAML's "__metadata__:", TOML's "[__metadata__]" or
+	"__metadata__ = { ... }". See metaDataSection().
+	*/
+	sourceMetaDataRegexp = regexp.MustCompile(`(?mi)^([ \t]*)(\[[ \t]*)?["']?__metadata__["']?[ \t]*[]:=]`)
+
+	/*
+	sourceCharsetRegexp is used to find the charset declaration
+	in the source's metadata section before decoding. See SourceItem.transcode().
+	*/
+	sourceCharsetRegexp = regexp.MustCompile(`(?i)(?:^|[\s{,])["']?charset["']?[ \t]*[:=][ \t]*["']?([A-Za-z0-9_.:-]+)`)
+
+	/*
+	metaDataLocaleKeysDefault is a set of metadata's keys (in lower case)
+	the locale name is looked up by, if Config.MetadataLocaleKeys is not set.
+	*/
+	metaDataLocaleKeysDefault = []string{"locale_name", "localename", "locale", "name"}
 )
 
+/*
+isFile reports whether the current SourceItem represents a file,
+meaning its Path is a real filepath, a locale name could be found in.
+*/
+func (si *SourceItem) isFile() bool {
+	return si.Type < SOURCE_ITEM_TYPE_CONTENT_UNKNOWN
+}
+
 /*
 loadMetaData tries to parse root considering that this
 is a root of sourced locale document that must contain some metadata about itself
 like locale name, etc.
+
+localeKeys is a set of metadata's keys (in lower case) the locale name is looked up by.
+If strict is true, the metadata's keys privet doesn't know about lead to an error.
 */
-func (si *SourceItem) loadMetaData(root map[string]interface{}) *ekaerr.Error {
+func (si *SourceItem) loadMetaData(root map[string]interface{}, localeKeys []string, strict bool) *ekaerr.Error {
 	const s = "Failed to find or parse metadata of content. "
 
 	var (
 		metaDataOriginalKey string
 		metaData            interface{}
 		metaDataMap         map[string]interface{}
+		unknownKeys         []string
 	)
 
 	for key, value := range root {
@@ -102,9 +138,9 @@ func (si *SourceItem) loadMetaData(root map[string]interface{}) *ekaerr.Error {
 
 	// Extract locale name
 	for key, value := range metaDataMap {
-		switch strings.ToLower(key) {
+		switch lowerKey := strings.ToLower(key); {
 
-		case "locale_name", "localename", "locale", "name":
+		case isOneOf(lowerKey, localeKeys):
 			if t := reflect2.TypeOf(value); t.RType() == ekaunsafe.RTypeString() {
 				if si.LocaleName == "" {
 					t.UnsafeSet(unsafe.Pointer(&si.LocaleName), ekaunsafe.TakeRealAddr(value))
@@ -124,9 +160,77 @@ func (si *SourceItem) loadMetaData(root map[string]interface{}) *ekaerr.Error {
 						"privet_metadata_locale_name_type", t.String()).
 					Throw()
 			}
+
+		case lowerKey == "list_separator" || lowerKey == "list_conjunction":
+			str, isString := value.(string)
+			if !isString {
+				return ekaerr.IllegalFormat.
+					New(s + "Metadata found, but list formatting rule has an incorrect type.").
+					AddFields(
+						"privet_metadata_key",       metaDataOriginalKey,
+						"privet_metadata_rule",      key,
+						"privet_metadata_rule_type", reflect2.TypeOf(value).String()).
+					Throw()
+			}
+			if lowerKey == "list_separator" {
+				si.listFormat.separator = str
+			} else {
+				si.listFormat.conjunction = str
+			}
+
+		case lowerKey == "required_args":
+			if err := si.loadRequiredArgs(value); err.IsNotNil() {
+				return err.
+					AddMessage(s).
+					AddFields("privet_metadata_key", metaDataOriginalKey).
+					Throw()
+			}
+
+		case lowerKey == "inherits":
+			if t := reflect2.TypeOf(value); t.RType() == ekaunsafe.RTypeString() {
+				si.inherits = value.(string)
+			} else {
+				return ekaerr.IllegalFormat.
+					New(s + "Metadata found, but parent locale name has an incorrect type.").
+					AddFields(
+						"privet_metadata_key",           metaDataOriginalKey,
+						"privet_metadata_inherits_type", t.String()).
+					Throw()
+			}
+
+		case lowerKey == "fallback" || lowerKey == "fallbacks":
+			if len(si.fallbacks) != 0 {
+				return ekaerr.IllegalFormat.
+					New(s + "Metadata found, but fallback locales are ambiguous. " +
+						"Found both of \"fallback\" and \"fallbacks\" fields.").
+					AddFields("privet_metadata_key", metaDataOriginalKey).
+					Throw()
+			}
+			if err := si.loadFallbacks(value); err.IsNotNil() {
+				return err.
+					AddMessage(s).
+					AddFields("privet_metadata_key", metaDataOriginalKey).
+					Throw()
+			}
+
+		case lowerKey == "charset":
+			// Already handled by transcode().
+
+		default:
+			unknownKeys = append(unknownKeys, key)
 		}
 	}
 
+	if strict && len(unknownKeys) != 0 {
+		sort.Strings(unknownKeys)
+		return ekaerr.IllegalFormat.
+			New(s + "Metadata found, but has unknown fields. Maybe a typo?").
+			AddFields(
+				"privet_metadata_key",          metaDataOriginalKey,
+				"privet_metadata_unknown_keys", strings.Join(unknownKeys, ", ")).
+			Throw()
+	}
+
 	// Validate locale name
 	switch {
 
@@ -138,18 +242,288 @@ func (si *SourceItem) loadMetaData(root map[string]interface{}) *ekaerr.Error {
 
 	case !isValidLocaleName(si.LocaleName):
 		return ekaerr.IllegalFormat.
-			New(s + "Metadata found but locale name has an incorrect format. Should be: xx_YY.").
+			New(s + "Metadata found but locale name has an incorrect format. Should be: xx_YY, xx_Scrp_YY or xx.").
 			AddFields("privet_metadata_key", metaDataOriginalKey).
 			Throw()
+
+	case si.inherits != "" && !isValidLocaleName(si.inherits):
+		return ekaerr.IllegalFormat.
+			New(s + "Metadata found but parent locale name has an incorrect format. Should be: xx_YY, xx_Scrp_YY or xx.").
+			AddFields("privet_metadata_key", metaDataOriginalKey).
+			Throw()
+
+	case si.inherits == si.LocaleName:
+		return ekaerr.IllegalFormat.
+			New(s + "Metadata found but locale inherits itself.").
+			AddFields("privet_metadata_key", metaDataOriginalKey).
+			Throw()
+	}
+
+	for _, fallback := range si.fallbacks {
+		switch {
+
+		case !isValidLocaleName(fallback):
+			return ekaerr.IllegalFormat.
+				New(s + "Metadata found but fallback locale name has an incorrect format. Should be: xx_YY, xx_Scrp_YY or xx.").
+				AddFields(
+					"privet_metadata_key",      metaDataOriginalKey,
+					"privet_metadata_fallback", fallback).
+				Throw()
+
+		case fallback == si.LocaleName:
+			return ekaerr.IllegalFormat.
+				New(s + "Metadata found but locale falls back to itself.").
+				AddFields("privet_metadata_key", metaDataOriginalKey).
+				Throw()
+		}
+	}
+
+	return nil
+}
+
+/*
+loadRequiredArgs parses the value of metadata's "required_args" field,
+that must be an object of translation key to the list of args names
+(or one arg name), that the phrase must contain the verbs of:
+
+        required_args:
+          greeting: [name]
+          Menu/Welcome: [user, count]
+
+See checkRequiredArgs() for more details.
+*/
+func (si *SourceItem) loadRequiredArgs(value interface{}) *ekaerr.Error {
+	const s = "Failed to parse required args. "
+
+	requiredArgsMap, ok := value.(map[string]interface{})
+	if !ok {
+		return ekaerr.IllegalFormat.
+			New(s + "Required args has an incorrect type. Should be an object.").
+			AddFields("privet_metadata_required_args_type", reflect2.TypeOf(value).String()).
+			Throw()
+	}
+
+	si.requiredArgs = make(map[string][]string, len(requiredArgsMap))
+
+	for key, args := range requiredArgsMap {
+		var requiredArgs []string
+
+		switch typedArgs := args.(type) {
+		case string:
+			requiredArgs = append(requiredArgs, typedArgs)
+		case []interface{}:
+			for _, arg := range typedArgs {
+				if argName, ok := arg.(string); ok {
+					requiredArgs = append(requiredArgs, argName)
+					continue
+				}
+				return ekaerr.IllegalFormat.
+					New(s + "Required arg's name has an incorrect type. Should be a string.").
+					AddFields(
+						"privet_metadata_required_args_key", key,
+						"privet_metadata_required_arg_type", reflect2.TypeOf(arg).String()).
+					Throw()
+			}
+		default:
+			return ekaerr.IllegalFormat.
+				New(s + "Required args of key has an incorrect type. Should be an array of strings.").
+				AddFields(
+					"privet_metadata_required_args_key",  key,
+					"privet_metadata_required_args_type", reflect2.TypeOf(args).String()).
+				Throw()
+		}
+
+		si.requiredArgs[key] = requiredArgs
+	}
+
+	return nil
+}
+
+/*
+loadFallbacks parses the value of metadata's "fallback" (or "fallbacks") field,
+that must be a fallback locale name or an array of them, in the order
+they are consulted in.
+*/
+func (si *SourceItem) loadFallbacks(value interface{}) *ekaerr.Error {
+	const s = "Failed to parse fallback locales. "
+
+	switch typedValue := value.(type) {
+	case string:
+		si.fallbacks = []string{typedValue}
+	case []interface{}:
+		fallbacks := make([]string, 0, len(typedValue))
+		for _, fallback := range typedValue {
+			fallbackName, ok := fallback.(string)
+			if !ok {
+				return ekaerr.IllegalFormat.
+					New(s + "Fallback locale name has an incorrect type. Should be a string.").
+					AddFields("privet_metadata_fallback_type", reflect2.TypeOf(fallback).String()).
+					Throw()
+			}
+			fallbacks = append(fallbacks, fallbackName)
+		}
+		si.fallbacks = fallbacks
+	default:
+		return ekaerr.IllegalFormat.
+			New(s + "Fallback locales have an incorrect type. Should be a string or an array of strings.").
+			AddFields("privet_metadata_fallbacks_type", reflect2.TypeOf(value).String()).
+			Throw()
 	}
 
 	return nil
 }
 
+/*
+resetLoaded returns the current SourceItem to the state it had before loading,
+dropping its locale name (unless it's passed to Source()) and metadata,
+so it can be loaded again from its content.
+*/
+func (si *SourceItem) resetLoaded() {
+	si.LocaleName = si.sourcedName
+	si.inherits = ""
+	si.fallbacks = nil
+	si.listFormat = listFormat{}
+	si.requiredArgs = nil
+}
+
+/*
+transcode returns the content of the current SourceItem converted to the UTF-8,
+if it's in another charset, declared either by SourceOptions.Charset
+or by metadata's "charset" field. The content itself is not changed,
+because the source might be loaded again.
+
+The metadata can't be decoded before transcoding, so its section
+(see metaDataSection()) is sniffed for the field like "charset: windows-1251"
+or "charset = "koi8-r"" instead.
+It works because all supported charsets are ASCII compatible.
+*/
+func (si *SourceItem) transcode() ([]byte, *ekaerr.Error) {
+	const s = "Failed to transcode content to UTF-8. "
+
+	charset := si.opts.Charset
+	if charset == "" {
+		if match := sourceCharsetRegexp.FindSubmatch(metaDataSection(si.content)); match != nil {
+			charset = string(match[1])
+		}
+	}
+
+	// PO file declares its charset by "Content-Type" field of its header,
+	// which is "charset=CHARSET" placeholder for not filled templates.
+
+	if charset == "" && (si.Type == SOURCE_ITEM_TYPE_FILE_PO || si.Type == SOURCE_ITEM_TYPE_CONTENT_PO) {
+		if match := poCharsetRegexp.FindSubmatch(si.content); match != nil &&
+			!strings.EqualFold(string(match[1]), "CHARSET") {
+
+			charset = string(match[1])
+		}
+	}
+
+	if charset == "" {
+		return si.content, nil
+	}
+
+	enc, legacyErr := htmlindex.Get(charset)
+	if legacyErr != nil {
+		return nil, ekaerr.IllegalFormat.
+			Wrap(legacyErr, s + "Unknown charset.").
+			AddFields("privet_source_charset", charset).
+			Throw()
+	}
+
+	if name, _ := htmlindex.Name(enc); name == "utf-8" {
+		return si.content, nil
+	}
+
+	content, legacyErr := enc.NewDecoder().Bytes(si.content)
+	if legacyErr != nil {
+		return nil, ekaerr.IllegalFormat.
+			Wrap(legacyErr, s + "Content is malformed.").
+			AddFields("privet_source_charset", charset).
+			Throw()
+	}
+
+	return content, nil
+}
+
+/*
+metaDataSection returns the part of not decoded content, that is a metadata section
+(see SourceItem.loadMetaData()), or nil if it's not found. The section is:
+ - the rest of "__metadata__" line and the following lines that are indented deeper
+   (YAML's mapping or flow mapping, TOML's inline table),
+ - the lines following "[__metadata__]" until the next TOML's table.
+*/
+func metaDataSection(content []byte) []byte {
+
+	match := sourceMetaDataRegexp.FindSubmatchIndex(content)
+	if match == nil {
+		return nil
+	}
+
+	var (
+		indent  = match[3] - match[2]
+		isTable = match[4] != -1
+		section = content[match[1]:]
+	)
+
+	for lineStart := bytes.IndexByte(section, '\n') + 1; lineStart != 0; {
+		line := section[lineStart:]
+		if lineEnd := bytes.IndexByte(line, '\n'); lineEnd != -1 {
+			line = line[:lineEnd]
+		}
+
+		trimmedLine := bytes.TrimLeft(line, " \t")
+		isSkipped := len(bytes.TrimSpace(trimmedLine)) == 0 || trimmedLine[0] == '#'
+
+		switch {
+		case isSkipped:
+		case isTable && trimmedLine[0] == '[':
+			return section[:lineStart]
+		case !isTable && len(line) - len(trimmedLine) <= indent:
+			return section[:lineStart]
+		}
+
+		lineStart += len(line) + 1
+		if lineStart > len(section) {
+			break
+		}
+	}
+
+	return section
+}
+
+/*
+hasMetaData reports whether root has a metadata section (see loadMetaData()).
+*/
+func hasMetaData(root map[string]interface{}) bool {
+	for key := range root {
+		if strings.ToLower(key) == "__metadata__" {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+isOneOf reports whether s is one of the passed strings.
+*/
+func isOneOf(s string, of []string) bool {
+	for i, n := 0, len(of); i < n; i++ {
+		if of[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
 /*
 findLocaleInFilepath tries to find a locale name in the current SourceItem's filepath.
 Any part of filepath MAY contain (it's not necessary to be exactly equal)
 a locale name. If it so, it will be parsed and associated with the current SourceItem.
+Locale name might contain a script subtag (e.g: "zh_Hans_CN"), its subtags
+must be registered ones, and 3 letters languages (e.g: "fil_PH") are looked up
+in the file name only.
+Language-only locale name (e.g: "en") is used only if it's the whole file name
+w/o extension (e.g: "en.yaml").
 
 Returns nil if filepath don't have a locale name,
 but an error if contain more than one.
@@ -170,14 +544,18 @@ func (si *SourceItem) findLocaleInFilepath() *ekaerr.Error {
 		tmp = make([]string, 0, 32)
 	)
 
-	for _, filePathPart := range strings.Split(
+	filePathParts := strings.Split(
 		si.Path[len(filepath.VolumeName(si.Path)):], // si.Path w/o volume
 		string(filepath.Separator),                  // splits by os.PathSeparator
-	) {
+	)
+
+	for filePathPartIdx, filePathPart := range filePathParts {
 		if filePathPart == "" {
 			continue
 		}
 
+		isFileName := filePathPartIdx == len(filePathParts)-1
+
 		tmp = tmp[:0]
 
 		for
@@ -193,15 +571,25 @@ func (si *SourceItem) findLocaleInFilepath() *ekaerr.Error {
 		tmp = append(tmp, filePathPart)
 
 		// Analyse pathPartParts.
-		// Concatenate sequences of "xx", "_", "YY" if there any.
+		// Concatenate sequences of "xx", "_", "Scrp", "_", "YY"
+		// or "xx", "_", "YY" if there any (the longest one is preferred)
+		// and their subtags are registered (see isKnownFullLocaleName()).
 		// Remove separators (from ALLOWED_SEPARATORS) otherwise.
 
 		for i, n := 0, len(tmp); i < n; i++ {
-			if i < n-2  && len(tmp[i]) + len(tmp[i+1]) + len(tmp[i+2]) == 5 {
-				if s := tmp[i] + tmp[i+1] + tmp[i+2]; isValidLocaleName(s) {
+			concatenated := 0
+			for _, m := range [...]int{5, 3} {
+				if i+m > n {
+					continue
+				}
+				if s := strings.Join(tmp[i:i+m], ""); isKnownFullLocaleName(s, isFileName) {
 					pathParts = append(pathParts, s)
-					i += 2
+					concatenated = m
+					break
 				}
+			}
+			if concatenated != 0 {
+				i += concatenated - 1
 			} else if len(tmp[i]) > 1 || strings.IndexByte(SEPARATORS, tmp[i][0]) == -1 {
 				pathParts = append(pathParts, tmp[i])
 			}
@@ -209,7 +597,7 @@ func (si *SourceItem) findLocaleInFilepath() *ekaerr.Error {
 	}
 
 	for _, pathPart := range pathParts {
-		switch proceed := isValidLocaleName(pathPart); {
+		switch proceed := isValidFullLocaleName(pathPart); {
 
 		case proceed && si.LocaleName == "":
 			si.LocaleName = pathPart
@@ -221,5 +609,21 @@ func (si *SourceItem) findLocaleInFilepath() *ekaerr.Error {
 		}
 	}
 
+	if si.LocaleName != "" {
+		return nil
+	}
+
+	// Language-only locale names (e.g: "en") are alike the words of names
+	// of directories and files (e.g: "ui", "db", "my"). So, it's used only
+	// if it's the whole file name w/o extension (e.g: "en.yaml", but not "db.en.json"),
+	// and the language is registered.
+
+	fileName := filepath.Base(si.Path)
+	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+
+	if isKnownLanguageName(fileName) {
+		si.LocaleName = fileName
+	}
+
 	return nil
 }
@@ -0,0 +1,77 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceItemFindLocaleInFilepath(t *testing.T) {
+
+	tests := []struct {
+		path     string
+		expected string
+		isFailed bool
+	}{
+		{"/locales/en_US.yaml", "en_US", false},
+		{"/locales/en.yaml", "en", false},
+		{"/locales/fil.yaml", "fil", false},
+		{"/locales/ui.yaml", "", false},
+		{"/locales/db.toml", "", false},
+		{"/locales/messages.en.json", "", false},
+		{"/locales/it/messages.yaml", "", false},
+		{"/locales/en_US/ru_RU.yaml", "", true},
+		{"/locales/zh_Hans_CN.yaml", "zh_Hans_CN", false},
+		{"/locales/sr_Latn/messages.yaml", "sr_Latn", false},
+		{"/locales/fil_PH.yaml", "fil_PH", false},
+		{"/home/dev_QA/locales/en_US.yaml", "en_US", false},
+		{"/home/my_Docs/locales/en_US.yaml", "en_US", false},
+		{"/home/my_Docs/locales/messages.yaml", "", false},
+		{"/locales/my_Test.yaml", "", false},
+	}
+
+	for _, test := range tests {
+		si := SourceItem{Type: SOURCE_ITEM_TYPE_FILE_YAML, Path: filepath.FromSlash(test.path)}
+		err := si.findLocaleInFilepath()
+
+		switch {
+		case err.IsNotNil() != test.isFailed:
+			t.Errorf("%s: is failed: %t, expected: %t", test.path, err.IsNotNil(), test.isFailed)
+		case !test.isFailed && si.LocaleName != test.expected:
+			t.Errorf("%s: locale name = %q, expected %q", test.path, si.LocaleName, test.expected)
+		}
+	}
+}
+
+func TestMetaDataSectionCharset(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"yaml", "__metadata__:\n  locale: ru_RU\n  charset: koi8-r\nkey: value\n", "koi8-r"},
+		{"yaml flow", "__metadata__: {locale: ru_RU, charset: koi8-r}\n", "koi8-r"},
+		{"yaml outside", "__metadata__:\n  locale: ru_RU\ncharset: koi8-r\n", ""},
+		{"yaml phrase", "charset: koi8-r\n__metadata__:\n  locale: ru_RU\n", ""},
+		{"yaml nested", "__metadata__:\n  locale: ru_RU\nForm:\n  charset: koi8-r\n", ""},
+		{"toml", "charset = \"utf-8\"\n[__metadata__]\n# comment\ncharset = \"koi8-r\"\n", "koi8-r"},
+		{"toml outside", "[__metadata__]\nlocale = \"ru_RU\"\n[Form]\ncharset = \"koi8-r\"\n", ""},
+		{"toml inline", "__metadata__ = { locale = \"ru_RU\", charset = \"koi8-r\" }\n", "koi8-r"},
+		{"no metadata", "charset: koi8-r\n", ""},
+	}
+
+	for _, test := range tests {
+		var charset string
+		if match := sourceCharsetRegexp.FindSubmatch(metaDataSection([]byte(test.content))); match != nil {
+			charset = string(match[1])
+		}
+		if charset != test.expected {
+			t.Errorf("%s: charset = %q, expected %q", test.name, charset, test.expected)
+		}
+	}
+}
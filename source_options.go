@@ -0,0 +1,73 @@
+// Copyright © 2020. All rights reserved.
+// Author: Ilya Stroy.
+// Contacts: qioalice@gmail.com, https://github.com/qioalice
+// License: https://opensource.org/licenses/MIT
+
+package privet
+
+import (
+	"strings"
+
+	"github.com/qioalice/ekago/v2/ekaerr"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+type (
+	/*
+	SourceOptions are options of sources, that are passed to the one
+	Client.SourceWithOptions() call. Zero value means the default behaviour.
+	*/
+	SourceOptions struct {
+
+		/*
+		Charset is a name of sources content's encoding
+		(e.g: "windows-1251", "koi8-r", "iso-8859-1"). See the WHATWG Encoding Standard
+		for supported names. Content is transcoded to the UTF-8 before decoding.
+		MD5 hash sum is calculated using the original content.
+
+		The charset might be also declared in metadata of source:
+
+		        __metadata__:
+		          locale: ru_RU
+		          charset: windows-1251
+
+		Empty means UTF-8 or the one declared in metadata.
+		Charset of SourceOptions has priority over the metadata's one.
+		*/
+		Charset string
+
+		/*
+		Overwrite allows the phrases of the sources of that call to overwrite
+		the already existing phrases with the same translation keys
+		(from the sources that are loaded before), even if Config.OverwriteExistingKey
+		is disabled. It's useful for layering, e.g: the base locales
+		that must not overwrite each other, and the per-tenant overrides:
+
+		        privet.Source("locales/base")
+		        privet.SourceWithOptions(privet.SourceOptions{Overwrite: true}, "locales/tenant")
+		        privet.Load()
+
+		False means Config.OverwriteExistingKey is used.
+		*/
+		Overwrite bool
+	}
+)
+
+/*
+validate returns an error if the current SourceOptions are malformed.
+*/
+func (so *SourceOptions) validate() *ekaerr.Error {
+	const s = "Source options are invalid. "
+
+	if so.Charset = strings.TrimSpace(so.Charset); so.Charset != "" {
+		if _, legacyErr := htmlindex.Get(so.Charset); legacyErr != nil {
+			return ekaerr.IllegalArgument.
+				Wrap(legacyErr, s + "Unknown charset.").
+				AddFields("privet_source_charset", so.Charset).
+				Throw()
+		}
+	}
+
+	return nil
+}
@@ -25,6 +25,9 @@ const (
 
 	_SPTR_TRANSLATION_KEY_IS_INCORRECT = __SPTR_PREFIX +
 		_SpecialTranslationClass("TranslationKeyIsIncorrect") + __SPTR_SUFFIX
+
+	_SPTR_TRANSLATION_KEY_IS_NODE = __SPTR_PREFIX +
+		_SpecialTranslationClass("TranslationKeyIsNode") + __SPTR_SUFFIX
 )
 
 /*